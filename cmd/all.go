@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v90/github"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/backstage-catalog-importer/cmd/charts"
+	"github.com/giantswarm/backstage-catalog-importer/cmd/crd"
+	"github.com/giantswarm/backstage-catalog-importer/cmd/groups"
+	"github.com/giantswarm/backstage-catalog-importer/cmd/installations"
+	"github.com/giantswarm/backstage-catalog-importer/cmd/users"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/config"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/export"
+)
+
+var allCmd = &cobra.Command{
+	Use:   "all",
+	Short: "Run several exporters in one process",
+	Long: `Runs the exporters selected via --exporters, based on the configuration file
+given via --config. By default, all exporters having a section in the
+configuration file are run. Without a configuration file, all exporters are
+run with default settings.
+
+All exporters share one rate-limited GitHub client, which caches responses.
+Exporters that fail don't stop the others. In the end, a summary of all
+written files and of all errors is printed.
+
+Exporter names: ` + strings.Join(config.Exporters, ", "),
+	Args:          cobra.NoArgs,
+	RunE:          runAll,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+const (
+	exportersFlag       = "exporters"
+	requestIntervalFlag = "request-interval"
+)
+
+func init() {
+	allCmd.Flags().StringSlice(exportersFlag, nil, "Comma-separated list of exporters to run. Default: all exporters configured")
+	allCmd.Flags().Int("concurrency", 4, "Number of repositories and charts to process in parallel when exporting components and charts")
+	allCmd.Flags().Duration(requestIntervalFlag, 100*time.Millisecond, "Minimum interval between GitHub API requests. 0 disables rate limiting")
+}
+
+// exportResult is the outcome of one export, e.g. the groups of one
+// organization.
+type exportResult struct {
+	// Name of the export, e.g. "groups (giantswarm)".
+	name      string
+	summaries []export.Summary
+	err       error
+
+	// Skipped repository list entries, for components only.
+	stale []staleEntry
+}
+
+func runAll(cmd *cobra.Command, args []string) error {
+	cfg, err := config.FromFlags(cmd.Root().PersistentFlags())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	path, err := config.String(cmd.Root().PersistentFlags(), "output", cfg.Output)
+	if err != nil {
+		return err
+	}
+
+	exporters, err := cmd.Flags().GetStringSlice(exportersFlag)
+	if err != nil {
+		return err
+	}
+	if len(exporters) == 0 {
+		exporters = cfg.ConfiguredExporters()
+	}
+	for _, name := range exporters {
+		if !slices.Contains(config.Exporters, name) {
+			return fmt.Errorf("unknown exporter %q, must be one of: %s", name, strings.Join(config.Exporters, ", "))
+		}
+	}
+
+	concurrency, err := cmd.Flags().GetInt("concurrency")
+	if err != nil {
+		return err
+	}
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1, got %d", concurrency)
+	}
+
+	requestInterval, err := cmd.Flags().GetDuration(requestIntervalFlag)
+	if err != nil {
+		return err
+	}
+
+	creds, err := httpclient.CredentialsFromEnv()
+	if err != nil {
+		return err
+	}
+
+	host, err := config.GitHubHost(cmd.Root().PersistentFlags(), cfg)
+	if err != nil {
+		return err
+	}
+	if creds.IsZero() {
+		log.Println("WARN: No GitHub credentials set. Using unauthenticated requests, which fails for most exporters.")
+	}
+
+	clientOptions, err := config.GitHubClientOptions(cmd.Root().PersistentFlags(), cfg)
+	if err != nil {
+		return err
+	}
+	quota := httpclient.NewQuota()
+	clientOptions = append(clientOptions,
+		httpclient.WithRequestInterval(requestInterval),
+		httpclient.WithResponseCache(),
+		httpclient.WithQuota(quota),
+		httpclient.WithAppCredentials(creds.App),
+	)
+	client, err := httpclient.NewGitHubClient(creds.Token, clientOptions...)
+	if err != nil {
+		return fmt.Errorf("could not create GitHub client: %w", err)
+	}
+	defer log.Printf("GitHub API quota used: %s", quota)
+
+	var results []exportResult
+	// Run in the canonical order, regardless of the order given.
+	for _, exporter := range config.Exporters {
+		if !slices.Contains(exporters, exporter) {
+			continue
+		}
+		log.Printf("Running exporter %s", exporter)
+		results = append(results, runExporter(exporter, cfg, client, host, path, creds, concurrency)...)
+	}
+
+	return reportResults(results)
+}
+
+// runExporter runs one exporter for every organization it is configured
+// for.
+func runExporter(exporter string, cfg *config.Config, client *github.Client, host githubhost.Host, path string, creds httpclient.Credentials, concurrency int) []exportResult {
+	var results []exportResult
+
+	switch exporter {
+	case config.ExporterUsers:
+		summary, err := users.Export(client, *cfg.Users, path)
+		return []exportResult{{name: exporter, summaries: []export.Summary{summary}, err: err}}
+	case config.ExporterCharts:
+		summaries, err := charts.Export(*cfg.Charts, host, path, 0, concurrency)
+		return []exportResult{{name: exporter, summaries: summaries, err: err}}
+	case config.ExporterCRD:
+		summary, err := crd.Export(client, host, *cfg.CRD, path)
+		return []exportResult{{name: exporter, summaries: []export.Summary{summary}, err: err}}
+	}
+
+	for _, org := range cfg.Organizations {
+		name := fmt.Sprintf("%s (%s)", exporter, org.Name)
+
+		switch {
+		case exporter == config.ExporterComponents && org.Components != nil:
+			summaries, stale, err := exportComponents(client, host, *org.Components, org.Name, path, creds, concurrency)
+			results = append(results, exportResult{name: name, summaries: summaries, err: err, stale: stale})
+		case exporter == config.ExporterGroups && org.Groups != nil:
+			summary, err := groups.Export(client, host, org.Name, *org.Groups, path)
+			results = append(results, exportResult{name: name, summaries: []export.Summary{summary}, err: err})
+		case exporter == config.ExporterInstallations && org.Installations != nil:
+			summary, err := installations.Export(client, host, org.Name, *org.Installations, path)
+			results = append(results, exportResult{name: name, summaries: []export.Summary{summary}, err: err})
+		}
+	}
+
+	if len(results) == 0 {
+		err := fmt.Errorf("no organization with %s configured", exporter)
+		results = append(results, exportResult{name: exporter, err: err})
+	}
+
+	return results
+}
+
+// reportResults prints the written files and the skipped repository list
+// entries of all exports, and returns an error listing all failed exports,
+// if any.
+func reportResults(results []exportResult) error {
+	var errs []error
+	var stale []staleEntry
+
+	fmt.Println("\nSummary:")
+	for _, r := range results {
+		stale = append(stale, r.stale...)
+		if r.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.name, r.err))
+			fmt.Printf("  %s: FAILED\n", r.name)
+			continue
+		}
+		for _, s := range r.summaries {
+			fmt.Printf("  %s: %s\n", r.name, s)
+		}
+	}
+
+	if len(stale) > 0 {
+		fmt.Printf("\nSkipped %s\n", staleReport(stale))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d exports failed:\n%w", len(errs), len(results), errors.Join(errs...))
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/config"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/export"
+)
+
+func TestRunExporterNotConfigured(t *testing.T) {
+	cfg, err := config.Read(strings.NewReader("organizations:\n- name: giantswarm\n  components: {}\n"))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, exporter := range []string{config.ExporterGroups, config.ExporterCharts, config.ExporterCRD} {
+		results := runExporter(exporter, cfg, nil, "", t.TempDir(), httpclient.Credentials{}, 1)
+		if len(results) != 1 || results[0].err == nil {
+			t.Errorf("runExporter(%q) = %v, want one failed result", exporter, results)
+		}
+	}
+}
+
+func TestReportResults(t *testing.T) {
+	ok := exportResult{name: "components (giantswarm)", summaries: []export.Summary{{Name: "components", Count: 1}}}
+
+	if err := reportResults([]exportResult{ok}); err != nil {
+		t.Errorf("reportResults() unexpected error %v", err)
+	}
+
+	err := reportResults([]exportResult{
+		ok,
+		{name: "groups (giantswarm)", err: errors.New("no token")},
+		{name: "charts", err: errors.New("no registry configured")},
+	})
+	if err == nil {
+		t.Fatal("reportResults() expected error")
+	}
+	for _, want := range []string{"2 of 3 exports failed", "groups (giantswarm): no token", "charts: no registry configured"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("reportResults() error %q does not contain %q", err, want)
+		}
+	}
+}
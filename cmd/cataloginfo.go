@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/cataloginfo"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/repositories"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/component"
+)
+
+// mergeCatalogInfo merges the hand-maintained catalog-info file of the
+// component's repository, if there is one, into the component. Problems
+// and conflicts are logged, but don't fail the export.
+func mergeCatalogInfo(repoService *repositories.Service, c *component.Component) {
+	path, data, err := repoService.LoadCatalogInfoFile(c.Name)
+	if repositories.IsFileNotFoundError(err) {
+		return
+	} else if err != nil {
+		log.Printf("WARN - %s - error fetching catalog-info file: %v", c.Name, err)
+		return
+	}
+
+	descriptor, err := cataloginfo.FindComponent([]byte(data), c.Name)
+	if err != nil {
+		log.Printf("WARN - %s - could not use %s: %v", c.Name, path, err)
+		return
+	}
+
+	log.Printf("DEBUG - %s - merging %s", c.Name, path)
+	for _, conflict := range c.Merge(descriptor.Metadata, descriptor.Spec) {
+		log.Printf("WARN - %s - %s: %s, keeping generated value", c.Name, path, conflict)
+	}
+}
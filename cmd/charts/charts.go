@@ -3,15 +3,25 @@ package charts
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"path"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 
+	"github.com/giantswarm/backstage-catalog-importer/pkg/config"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/input/ociregistry"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/api"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/component"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/output/export"
 	componentutil "github.com/giantswarm/backstage-catalog-importer/pkg/util/component"
@@ -24,7 +34,7 @@ type exportStats struct {
 }
 
 var Command = &cobra.Command{
-	Use:   "charts <registry>",
+	Use:   "charts [registry]",
 	Short: "Export OCI registry charts as Backstage entities",
 	Long: `The command connects to an OCI registry and exports Helm charts as Backstage component entities.
 
@@ -32,9 +42,15 @@ Charts are discovered by listing repositories with a specified prefix and extrac
 
 Only charts with the annotation io.giantswarm.application.audience set to "all" in the config blob are included in the output.
 
+Private registries are accessed with the credentials from the OCI_REGISTRY_USERNAME
+and OCI_REGISTRY_PASSWORD, or OCI_REGISTRY_TOKEN environment variables. Otherwise
+credentials are taken from the Docker config file, including credential helpers,
+as set up by 'docker login' or 'az acr login'.
+
 Arguments:
-  registry    OCI registry hostname (e.g., gsoci.azurecr.io)`,
-	Args: cobra.ExactArgs(1),
+  registry    OCI registry hostname (e.g., gsoci.azurecr.io). Can be omitted
+              if set in the configuration file.`,
+	Args: cobra.RangeArgs(0, 1),
 	Run:  runCharts,
 }
 
@@ -56,52 +72,127 @@ const (
 	audienceGiantSwarm = "giantswarm"
 
 	defaultComponentOwner = "group:unspecified"
+
+	// Values schema file in charts, published as API of the chart's
+	// component, named after the component with a suffix.
+	valuesSchemaFile    = "values.schema.json"
+	valuesAPIType       = "helm-values"
+	valuesAPINameSuffix = "-values"
+
+	// README file in charts, published as TechDocs of the chart's component.
+	readmeFile = "README.md"
 )
 
 func init() {
 	Command.PersistentFlags().StringP("prefix", "p", "", "Repository prefix to filter charts (optional)")
-	Command.PersistentFlags().StringP("namespace", "n", "default", "Backstage namespace for the components")
-	Command.PersistentFlags().StringP("type", "t", "service", "Component type")
+	Command.PersistentFlags().StringP("namespace", "n", config.DefaultNamespace, "Backstage namespace for the components")
+	Command.PersistentFlags().StringP("type", "t", config.DefaultChartsComponentType, "Component type")
 	Command.PersistentFlags().IntP("limit", "l", 0, "Limit the number of charts to process (0 = no limit, for testing)")
+	Command.PersistentFlags().Int("concurrency", 4, "Number of charts to process in parallel")
+	Command.PersistentFlags().String("docs-dir", config.DefaultChartsDocsDir, "Directory, relative to the output directory, to write TechDocs of charts with a README.md to. Empty to point TechDocs to the repositories' main branch instead")
 }
 
 func runCharts(cmd *cobra.Command, args []string) {
+	cfg, err := config.FromFlags(cmd.Root().PersistentFlags())
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
 	// Get registry hostname from positional argument
-	registryHostname := args[0]
+	registryHostname := cfg.Charts.Registry
+	if len(args) > 0 {
+		registryHostname = args[0]
+	}
+	if registryHostname == "" {
+		log.Fatal("Error: no registry given, neither as argument nor in the configuration file")
+	}
+
+	settings := *cfg.Charts
+	settings.Registry = registryHostname
 
-	prefix, err := cmd.PersistentFlags().GetString("prefix")
+	settings.Prefix, err = config.String(cmd.PersistentFlags(), "prefix", settings.Prefix)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	namespace, err := cmd.PersistentFlags().GetString("namespace")
+	settings.Namespace, err = config.String(cmd.PersistentFlags(), "namespace", settings.Namespace)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	componentType, err := cmd.PersistentFlags().GetString("type")
+	settings.Type, err = config.String(cmd.PersistentFlags(), "type", settings.Type)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// The docs directory may be set to an empty string deliberately.
+	if cmd.PersistentFlags().Changed("docs-dir") || settings.DocsDir == nil {
+		docsDir, err := cmd.PersistentFlags().GetString("docs-dir")
+		if err != nil {
+			log.Fatal(err)
+		}
+		settings.DocsDir = &docsDir
+	}
+
 	limit, err := cmd.PersistentFlags().GetInt("limit")
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	outputPath, err := cmd.Root().PersistentFlags().GetString("output")
+	concurrency, err := cmd.PersistentFlags().GetInt("concurrency")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if concurrency < 1 {
+		log.Fatalf("Error: --concurrency must be at least 1, got %d", concurrency)
+	}
+
+	outputPath, err := config.String(cmd.Root().PersistentFlags(), "output", cfg.Output)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	host, err := config.GitHubHost(cmd.Root().PersistentFlags(), cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	summaries, err := Export(settings, host, outputPath, limit, concurrency)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	fmt.Println()
+	for _, summary := range summaries {
+		fmt.Println(summary)
+	}
+}
+
+// Export exports the charts found in the registry configured in settings as
+// components to a file in the path directory, linked to repositories on the
+// given GitHub host, and their values schemas as APIs to another file. The
+// READMEs of charts are written as TechDocs to the configured docs
+// directory, if any. A limit greater than zero limits the number of charts
+// processed. Up to concurrency charts are processed in parallel. Registry
+// credentials are read from the environment variables named in package
+// ociregistry, or from the Docker config file.
+func Export(settings config.Charts, host githubhost.Host, path string, limit, concurrency int) ([]export.Summary, error) {
+	if settings.Registry == "" {
+		return nil, errors.New("no registry configured")
+	}
+
+	registryHostname := settings.Registry
+	prefix := settings.Prefix
+
 	ctx := context.Background()
 
 	// Create OCI registry client
 	registry, err := ociregistry.NewRegistry(ctx, ociregistry.Config{
-		Hostname: registryHostname,
+		Hostname:    registryHostname,
+		Credentials: ociregistry.CredentialsFromEnv(),
 	})
 	if err != nil {
-		log.Fatalf("Failed to create OCI registry client: %v", err)
+		return nil, fmt.Errorf("failed to create OCI registry client: %w", err)
 	}
 
 	log.Printf("Connected to OCI registry: %s", registryHostname)
@@ -109,7 +200,7 @@ func runCharts(cmd *cobra.Command, args []string) {
 	// List repositories
 	repositories, err := registry.ListRepositories(ctx, prefix)
 	if err != nil {
-		log.Fatalf("Failed to list repositories: %v", err)
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
 	}
 
 	log.Printf("Found %d repositories with prefix '%s'", len(repositories), prefix)
@@ -120,64 +211,53 @@ func runCharts(cmd *cobra.Command, args []string) {
 		repositories = repositories[:limit]
 	}
 
-	componentExporter := export.New(export.Config{TargetPath: outputPath + "/charts.yaml"})
+	componentExporter := export.New(export.Config{TargetPath: filepath.Join(path, settings.OutputFile)})
+	apiExporter := export.New(export.Config{TargetPath: filepath.Join(path, settings.APIsOutputFile)})
 	stats := &exportStats{
 		annotationCounts: make(map[string]int),
 	}
 
-	// Process each repository
-	for _, repo := range repositories {
-		log.Printf("Processing repository: %s", repo)
-
-		// List tags for the repository
-		tags, err := registry.ListRepositoryTags(ctx, repo)
+	opts := chartOptions{
+		namespace:        settings.Namespace,
+		componentType:    settings.Type,
+		registryHostname: registryHostname,
+		host:             host,
+	}
+	docsDir := config.DefaultChartsDocsDir
+	if settings.DocsDir != nil {
+		docsDir = *settings.DocsDir
+	}
+	if docsDir != "" {
+		// TechDocs references are relative to the components file.
+		docsRef, err := filepath.Rel(filepath.Dir(settings.OutputFile), docsDir)
 		if err != nil {
-			log.Printf("WARN: Failed to list tags for repository %s: %v", repo, err)
-			continue
+			return nil, fmt.Errorf("invalid docs directory: %w", err)
 		}
-
-		if len(tags) == 0 {
-			log.Printf("WARN: No tags found for repository %s", repo)
-			continue
-		}
-
-		// Prefer the latest pure semver release tag for metadata extraction,
-		// so the component reflects the latest release rather than a dev build.
-		// Dev builds (semver pre-releases like 1.1.22-dev....) and non-semver
-		// tags fall back to the highest tag; the version annotations are gated
-		// separately in createComponentFromOCIChart.
-		tag, hasRelease := ociregistry.LatestReleaseTag(tags)
-		if hasRelease {
-			log.Printf("Using release tag: %s", tag)
-		} else {
-			tag = tags[0]
-			log.Printf("No pure semver release tag found for %s; using %s for metadata, omitting version annotations", repo, tag)
-		}
-
-		// Get manifest for metadata extraction
-		manifestInfo, err := registry.GetRepositoryManifest(ctx, repo, tag)
-		if err != nil {
-			log.Printf("WARN: Failed to get manifest for %s:%s: %v", repo, tag, err)
-			continue
+		opts.docsRef = filepath.ToSlash(docsRef)
+	}
+	docs := &chartDocs{
+		dir:          filepath.Join(path, docsDir),
+		host:         host,
+		repositories: make(map[string]string),
+	}
+	err = processCharts(ctx, registry, repositories, opts, concurrency, func(result chartResult) error {
+		for _, message := range result.messages {
+			log.Print(message)
 		}
-
-		// Filter charts: only include charts with audience annotation set to "all"
-		if !shouldIncludeChart(manifestInfo.Config) {
-			log.Printf("Skipping chart %s:%s (audience annotation is not 'all')", repo, tag)
-			continue
+		if result.component == nil {
+			return nil
 		}
 
-		// Create component from repository and manifest data
-		comp, err := createComponentFromOCIChart(repo, tag, manifestInfo, namespace, componentType, registryHostname)
+		err := docs.publish(result)
 		if err != nil {
-			log.Printf("WARN: Failed to create component for %s:%s: %v", repo, tag, err)
-			continue
+			return err
 		}
 
+		comp := result.component
 		entity := comp.ToEntity()
 		err = componentExporter.AddEntity(entity)
 		if err != nil {
-			log.Fatalf("Error adding component entity: %v", err)
+			return fmt.Errorf("error adding component entity: %w", err)
 		}
 
 		// Track statistics
@@ -185,41 +265,332 @@ func runCharts(cmd *cobra.Command, args []string) {
 		stats.trackAnnotations(entity.Metadata.Annotations)
 
 		log.Printf("Created component: %s", comp.Name)
+
+		if result.api != nil {
+			err = apiExporter.AddEntity(result.api.ToEntity())
+			if err != nil {
+				return fmt.Errorf("error adding API entity: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Write the components file
 	err = componentExporter.WriteFile()
 	if err != nil {
-		log.Fatalf("Error writing components file: %v", err)
+		return nil, fmt.Errorf("error writing components file: %w", err)
 	}
 
-	fmt.Printf("\n%d components written to file %s with size %d bytes\n",
-		stats.total, componentExporter.TargetPath, componentExporter.Len())
+	// Write the values schema APIs file
+	err = apiExporter.WriteFile()
+	if err != nil {
+		return nil, fmt.Errorf("error writing APIs file: %w", err)
+	}
 
 	// Print statistics report
 	stats.printReport()
+
+	return []export.Summary{componentExporter.Summary("components"), apiExporter.Summary("values schema APIs")}, nil
+}
+
+// chartRegistry is the part of the OCI registry client used to process
+// charts.
+type chartRegistry interface {
+	ListRepositoryTags(ctx context.Context, repository string) ([]string, error)
+	GetRepositoryManifest(ctx context.Context, repository, tag string) (*ociregistry.ManifestInfo, error)
+	GetChartFiles(ctx context.Context, repository string, layer v1.Descriptor, names ...string) (map[string][]byte, error)
+}
+
+// chartOptions holds the settings for creating components from charts.
+type chartOptions struct {
+	namespace        string
+	componentType    string
+	registryHostname string
+	host             githubhost.Host
+
+	// Path of the docs directory relative to the components file, or empty
+	// to not publish chart READMEs.
+	docsRef string
+}
+
+// chartResult is the outcome of processing a chart repository: the
+// component, the API of its values schema and its README, if any, and the
+// messages to log.
+type chartResult struct {
+	repository string
+	component  *component.Component
+	api        *api.API
+	readme     []byte
+	messages   []string
+}
+
+// processCharts processes the chart repositories, up to concurrency in
+// parallel. Results are passed to yield in the order of repositories, each as
+// soon as it and all preceding ones are available, so that output order and
+// log messages per repository are the same as in sequential processing.
+// Processing stops at the first error returned by yield.
+func processCharts(ctx context.Context, registry chartRegistry, repositories []string, opts chartOptions, concurrency int, yield func(chartResult) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chartResult, len(repositories))
+	done := make([]chan struct{}, len(repositories))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	go func() {
+		g := new(errgroup.Group)
+		g.SetLimit(concurrency)
+		for i, repo := range repositories {
+			if ctx.Err() != nil {
+				break
+			}
+			g.Go(func() error {
+				results[i] = processChart(ctx, registry, repo, opts)
+				close(done[i])
+				return nil
+			})
+		}
+		_ = g.Wait()
+	}()
+
+	for i := range results {
+		<-done[i]
+		err := yield(results[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// createComponentFromOCIChart creates a Backstage component from OCI chart metadata
-func createComponentFromOCIChart(repo string, tag string, manifestInfo *ociregistry.ManifestInfo, namespace, componentType, registryHostname string) (*component.Component, error) {
+// processChart creates a component from the latest chart in the repository.
+// Problems are logged as warnings, without returning a component.
+func processChart(ctx context.Context, registry chartRegistry, repo string, opts chartOptions) chartResult {
+	result := chartResult{repository: repo}
+	logf := func(format string, args ...any) {
+		result.messages = append(result.messages, fmt.Sprintf(format, args...))
+	}
+
+	logf("Processing repository: %s", repo)
+
+	// List tags for the repository
+	tags, err := registry.ListRepositoryTags(ctx, repo)
+	if err != nil {
+		logf("WARN: Failed to list tags for repository %s: %v", repo, err)
+		return result
+	}
+
+	if len(tags) == 0 {
+		logf("WARN: No tags found for repository %s", repo)
+		return result
+	}
+
+	// Prefer the latest pure semver release tag for metadata extraction,
+	// so the component reflects the latest release rather than a dev build.
+	// Dev builds (semver pre-releases like 1.1.22-dev....) and non-semver
+	// tags fall back to the highest tag; the version annotations are gated
+	// separately in createComponentFromOCIChart.
+	tag, hasRelease := ociregistry.LatestReleaseTag(tags)
+	if hasRelease {
+		logf("Using release tag: %s", tag)
+	} else {
+		tag = tags[0]
+		logf("No pure semver release tag found for %s; using %s for metadata, omitting version annotations", repo, tag)
+	}
+
+	// Get manifest for metadata extraction
+	manifestInfo, err := registry.GetRepositoryManifest(ctx, repo, tag)
+	if err != nil {
+		logf("WARN: Failed to get manifest for %s:%s: %v", repo, tag, err)
+		return result
+	}
+
+	// Skip container images, signatures and other artifacts
+	if manifestInfo.Kind != ociregistry.ArtifactKindHelmChart {
+		logf("Skipping %s:%s (%s artifact of type %q, not a Helm chart)", repo, tag, manifestInfo.Kind, manifestInfo.ArtifactType)
+		return result
+	}
+
+	// Filter charts: only include charts with audience annotation set to "all"
+	if !shouldIncludeChart(manifestInfo.Config) {
+		logf("Skipping chart %s:%s (audience annotation is not 'all')", repo, tag)
+		return result
+	}
+
+	// Create component from repository and manifest data
+	comp, err := createComponentFromOCIChart(repo, tag, manifestInfo, opts.namespace, opts.componentType, opts.registryHostname, opts.host, logf)
+	if err != nil {
+		logf("WARN: Failed to create component for %s:%s: %v", repo, tag, err)
+		return result
+	}
+
+	if manifestInfo.ChartLayer == nil {
+		result.component = comp
+		return result
+	}
+
+	names := []string{valuesSchemaFile}
+	if opts.docsRef != "" {
+		if isPathSegment(comp.Name) {
+			names = append(names, readmeFile)
+		} else {
+			logf("WARN: Not publishing README of %s:%s, component name %q is not usable as directory name", repo, tag, comp.Name)
+		}
+	}
+	files, err := registry.GetChartFiles(ctx, repo, *manifestInfo.ChartLayer, names...)
+	if err != nil {
+		logf("WARN: Failed to read %s of %s:%s: %v", strings.Join(names, " and "), repo, tag, err)
+		result.component = comp
+		return result
+	}
+
+	// Publish the values schema as API provided by the component
+	if schema, ok := files[valuesSchemaFile]; ok {
+		valuesAPI, err := createValuesSchemaAPI(comp, schema)
+		if err != nil {
+			logf("WARN: Failed to create values schema API for %s:%s: %v", repo, tag, err)
+		} else {
+			comp.ProvidesAPIs = append(comp.ProvidesAPIs, valuesAPI.Name)
+			result.api = valuesAPI
+		}
+	}
+
+	// Point TechDocs to the README of this chart version instead of the
+	// repository's main branch
+	if readme, ok := files[readmeFile]; ok {
+		comp.SetAnnotation("backstage.io/techdocs-ref", "dir:"+path.Join(opts.docsRef, comp.Name))
+		result.readme = readme
+	}
+
+	result.component = comp
+	return result
+}
+
+// isPathSegment returns whether name can be used as the name of a directory
+// within the docs directory, without nesting or escaping it.
+func isPathSegment(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// mainBranchDocsRef returns the TechDocs reference to the root of the
+// repository's main branch.
+func mainBranchDocsRef(host githubhost.Host, githubProjectSlug string) string {
+	return "url:" + host.TreeURL(githubProjectSlug, "main", "")
+}
+
+// chartDocs publishes the READMEs of charts as TechDocs sites in dir.
+type chartDocs struct {
+	dir  string
+	host githubhost.Host
+
+	// Repositories whose README was published, by component name.
+	repositories map[string]string
+}
+
+// publish writes the README of the result's chart, if any. Charts resolving
+// to the same component name would write to the same directory, so only the
+// first one is published, and the others' TechDocs point to their
+// repository's main branch.
+func (d *chartDocs) publish(result chartResult) error {
+	if result.readme == nil {
+		return nil
+	}
+
+	comp := result.component
+	if other, exists := d.repositories[comp.Name]; exists {
+		log.Printf("WARN: Not publishing README of %s, component %s already has the README of %s", result.repository, comp.Name, other)
+		comp.SetAnnotation("backstage.io/techdocs-ref", mainBranchDocsRef(d.host, comp.GithubProjectSlug))
+		return nil
+	}
+	d.repositories[comp.Name] = result.repository
+
+	err := writeChartDocs(filepath.Join(d.dir, comp.Name), comp.Name, result.readme)
+	if err != nil {
+		return fmt.Errorf("error writing docs of %s: %w", comp.Name, err)
+	}
+	return nil
+}
+
+// writeChartDocs writes the chart's README as the only page of a TechDocs
+// site to dir.
+func writeChartDocs(dir, name string, readme []byte) error {
+	err := os.MkdirAll(filepath.Join(dir, "docs"), 0750)
+	if err != nil {
+		return err
+	}
+
+	mkdocs := fmt.Sprintf("site_name: %q\nnav:\n  - Home: index.md\nplugins:\n  - techdocs-core\n", name)
+	err = os.WriteFile(filepath.Join(dir, "mkdocs.yml"), []byte(mkdocs), 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, "docs", "index.md"), readme, 0600)
+}
+
+// createValuesSchemaAPI creates an API entity of type helm-values, defined
+// by the chart's values schema, owned by the chart's component.
+func createValuesSchemaAPI(comp *component.Component, schema []byte) (*api.API, error) {
+	var schemaFields struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	}
+	err := json.Unmarshal(schema, &schemaFields)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", valuesSchemaFile, err)
+	}
+
+	title := schemaFields.Title
+	if title == "" {
+		title = comp.Name + " values"
+	}
+	description := schemaFields.Description
+	if description == "" {
+		description = fmt.Sprintf("Configuration values of the %s Helm chart", comp.Name)
+	}
+
+	return api.New(comp.Name+valuesAPINameSuffix,
+		api.WithNamespace(comp.Namespace),
+		api.WithTitle(title),
+		api.WithDescription(description),
+		api.WithOwner(comp.Owner),
+		api.WithType(valuesAPIType),
+		api.WithDefinition(string(schema)),
+		api.WithTags("helm", "helmchart"),
+	)
+}
+
+// createComponentFromOCIChart creates a Backstage component from OCI chart
+// metadata. Warnings are passed to logf.
+func createComponentFromOCIChart(repo string, tag string, manifestInfo *ociregistry.ManifestInfo, namespace, componentType, registryHostname string, host githubhost.Host, logf func(format string, args ...any)) (*component.Component, error) {
 	configMap := manifestInfo.Config
 
 	// Extract GitHub project slug and repository name from home field (Helm chart config structure)
-	// Expected format: https://github.com/giantswarm/repository-name
+	// Expected format: https://github.com/giantswarm/repository-name, or the
+	// same on the configured GitHub host. Charts mirrored to a GitHub
+	// Enterprise Server usually still point to github.com.
 	var githubProjectSlug string
 	var githubRepoName string
 
 	if configMap != nil {
 		if home, ok := configMap["home"].(string); ok && home != "" {
-			const githubGiantSwarmPrefix = "https://github.com/giantswarm/"
-			if strings.HasPrefix(home, githubGiantSwarmPrefix) {
-				// Extract repository name from the URL and remove any trailing slashes
-				repoNameFromURL := strings.TrimPrefix(home, githubGiantSwarmPrefix)
+			for _, prefix := range []string{host.RepoURL("giantswarm/"), githubhost.Host(githubhost.Default).RepoURL("giantswarm/")} {
+				repoNameFromURL, found := strings.CutPrefix(home, prefix)
+				if !found {
+					continue
+				}
+				// Remove any trailing slashes
 				repoNameFromURL = strings.TrimSuffix(repoNameFromURL, "/")
 
 				// Build project slug and repository name
 				githubProjectSlug = "giantswarm/" + repoNameFromURL
 				githubRepoName = repoNameFromURL
+				break
 			}
 		}
 	}
@@ -287,20 +658,20 @@ func createComponentFromOCIChart(repo string, tag string, manifestInfo *ociregis
 					if managedValue, err := strconv.ParseBool(strVal); err == nil {
 						managed = managedValue
 					} else {
-						log.Printf("WARN: '%s' annotation value '%s' is not a valid boolean (expected 'true' or 'false') for %s:%s", managedOciAnnotation, strVal, repo, tag)
+						logf("WARN: '%s' annotation value '%s' is not a valid boolean (expected 'true' or 'false') for %s:%s", managedOciAnnotation, strVal, repo, tag)
 					}
 				} else {
-					log.Printf("WARN: '%s' annotation value is not a string for %s:%s", managedOciAnnotation, repo, tag)
+					logf("WARN: '%s' annotation value is not a string for %s:%s", managedOciAnnotation, repo, tag)
 				}
 			} else if val, exists := annotations[managedLegacyChartAnnotation]; exists {
 				if strVal, ok := val.(string); ok {
 					if managedValue, err := strconv.ParseBool(strVal); err == nil {
 						managed = managedValue
 					} else {
-						log.Printf("WARN: '%s' annotation value '%s' is not a valid boolean (expected 'true' or 'false') for %s:%s", managedLegacyChartAnnotation, strVal, repo, tag)
+						logf("WARN: '%s' annotation value '%s' is not a valid boolean (expected 'true' or 'false') for %s:%s", managedLegacyChartAnnotation, strVal, repo, tag)
 					}
 				} else {
-					log.Printf("WARN: '%s' annotation value is not a string for %s:%s", managedLegacyChartAnnotation, repo, tag)
+					logf("WARN: '%s' annotation value is not a string for %s:%s", managedLegacyChartAnnotation, repo, tag)
 				}
 			}
 
@@ -310,7 +681,7 @@ func createComponentFromOCIChart(repo string, tag string, manifestInfo *ociregis
 				audience = audienceValue
 			}
 			if audience != audienceAll && audience != audienceGiantSwarm {
-				log.Printf("WARN: audience annotation value '%s' is not a valid audience for %s:%s", audience, repo, tag)
+				logf("WARN: audience annotation value '%s' is not a valid audience for %s:%s", audience, repo, tag)
 				audience = audienceAll // back to default
 			}
 		}
@@ -328,7 +699,7 @@ func createComponentFromOCIChart(repo string, tag string, manifestInfo *ociregis
 
 	// Add GitHub project slug if available
 	if githubProjectSlug != "" {
-		componentOpts = append(componentOpts, component.WithGithubProjectSlug(githubProjectSlug))
+		componentOpts = append(componentOpts, component.WithGithubProjectSlug(githubProjectSlug), component.WithGithubHost(host))
 	}
 
 	// Create the component
@@ -337,9 +708,9 @@ func createComponentFromOCIChart(repo string, tag string, manifestInfo *ociregis
 		return nil, err
 	}
 
-	// Set techdocs-ref annotation pointing to the repo root on main branch
-	comp.SetAnnotation("backstage.io/techdocs-ref",
-		fmt.Sprintf("url:https://github.com/%s/tree/%s", githubProjectSlug, "main"))
+	// Set techdocs-ref annotation pointing to the repo root on main branch.
+	// processChart replaces it with the chart's README, if there is one.
+	comp.SetAnnotation("backstage.io/techdocs-ref", mainBranchDocsRef(host, githubProjectSlug))
 
 	// Add helmchart annotations
 	// Format: registry/repository (combining what was oci-registry and oci-repository)
@@ -1,12 +1,23 @@
 package charts
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/input/ociregistry"
 	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/component"
 )
 
 func TestCreateComponentFromOCIChart(t *testing.T) {
@@ -299,6 +310,8 @@ func TestCreateComponentFromOCIChart(t *testing.T) {
 				tt.namespace,
 				tt.componentType,
 				tt.registryHostname,
+				"",
+				t.Logf,
 			)
 
 			if (err != nil) != tt.wantErr {
@@ -398,6 +411,8 @@ func TestCreateComponentFromOCIChart_OmitVersions(t *testing.T) {
 				"default",
 				"service",
 				"registry.example.com",
+				"",
+				t.Logf,
 			)
 			if err != nil {
 				t.Fatalf("createComponentFromOCIChart() unexpected error: %v", err)
@@ -417,6 +432,38 @@ func TestCreateComponentFromOCIChart_OmitVersions(t *testing.T) {
 	}
 }
 
+func TestCreateComponentFromOCIChartEnterpriseHost(t *testing.T) {
+	host, err := githubhost.New("github.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, home := range []string{"https://github.example.com/giantswarm/foo-app", "https://github.com/giantswarm/foo-app"} {
+		t.Run(home, func(t *testing.T) {
+			manifestInfo := &ociregistry.ManifestInfo{Config: map[string]interface{}{
+				"name":        "foo",
+				"home":        home,
+				"annotations": map[string]interface{}{"application.giantswarm.io/audience": "all"},
+			}}
+
+			got, err := createComponentFromOCIChart("giantswarm/foo", "1.0.0", manifestInfo, "default", "service", "registry.example.com", host, t.Logf)
+			if err != nil {
+				t.Fatalf("createComponentFromOCIChart() unexpected error: %v", err)
+			}
+
+			annotations := got.ToEntity().Metadata.Annotations
+			for key, want := range map[string]string{
+				"backstage.io/source-location": "url:https://github.example.com/giantswarm/foo-app",
+				"backstage.io/techdocs-ref":    "url:https://github.example.com/giantswarm/foo-app/tree/main",
+			} {
+				if annotations[key] != want {
+					t.Errorf("annotation %s = %q, want %q", key, annotations[key], want)
+				}
+			}
+		})
+	}
+}
+
 // TestCreateComponentFromOCIChart_ErrorCases tests error scenarios
 func TestCreateComponentFromOCIChart_ErrorCases(t *testing.T) {
 	tests := []struct {
@@ -495,6 +542,8 @@ func TestCreateComponentFromOCIChart_ErrorCases(t *testing.T) {
 				tt.namespace,
 				tt.componentType,
 				tt.registryHostname,
+				"",
+				t.Logf,
 			)
 
 			if (err != nil) != tt.wantErr {
@@ -657,3 +706,330 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+// fakeRegistry serves charts named after their repositories, and an image
+// for charts/e-app. Only charts/a-app has a values schema and a README. It
+// responds slower for charts/a-app, and tracks the number of concurrent
+// requests.
+type fakeRegistry struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (r *fakeRegistry) request(repo string) func() {
+	r.mu.Lock()
+	r.inFlight++
+	r.maxInFlight = max(r.maxInFlight, r.inFlight)
+	r.mu.Unlock()
+
+	delay := 10 * time.Millisecond
+	if repo == "charts/a-app" {
+		delay = 50 * time.Millisecond
+	}
+	time.Sleep(delay)
+
+	return func() {
+		r.mu.Lock()
+		r.inFlight--
+		r.mu.Unlock()
+	}
+}
+
+func (r *fakeRegistry) ListRepositoryTags(ctx context.Context, repo string) ([]string, error) {
+	defer r.request(repo)()
+	if repo == "charts/b-app" {
+		return nil, errors.New("not found")
+	}
+	return []string{"1.1.0-dev", "1.0.0"}, nil
+}
+
+func (r *fakeRegistry) GetRepositoryManifest(ctx context.Context, repo, tag string) (*ociregistry.ManifestInfo, error) {
+	defer r.request(repo)()
+	name := repo[len("charts/"):]
+	if repo == "charts/e-app" {
+		return &ociregistry.ManifestInfo{Kind: ociregistry.ArtifactKindImage, ArtifactType: "application/vnd.oci.image.config.v1+json"}, nil
+	}
+	return &ociregistry.ManifestInfo{
+		Kind:       ociregistry.ArtifactKindHelmChart,
+		ChartLayer: &v1.Descriptor{MediaType: "application/vnd.cncf.helm.chart.content.v1.tar+gzip"},
+		Config: map[string]interface{}{
+			"home":    "https://github.com/giantswarm/" + name,
+			"version": tag,
+			"annotations": map[string]interface{}{
+				audienceOciAnnotation: audienceAll,
+			},
+		},
+	}, nil
+}
+
+func (r *fakeRegistry) GetChartFiles(ctx context.Context, repo string, layer v1.Descriptor, names ...string) (map[string][]byte, error) {
+	defer r.request(repo)()
+	if repo != "charts/a-app" {
+		return map[string][]byte{}, nil
+	}
+	files := map[string][]byte{}
+	for _, name := range names {
+		files[name] = []byte("content of " + name)
+	}
+	files[valuesSchemaFile] = []byte(`{"type": "object"}`)
+	return files, nil
+}
+
+func TestProcessCharts(t *testing.T) {
+	repositories := []string{"charts/a-app", "charts/b-app", "charts/c-app", "charts/d-app", "charts/e-app"}
+	registry := &fakeRegistry{}
+
+	var names []string
+	var apis []string
+	var readmes []string
+	var messages []string
+	opts := chartOptions{namespace: "giantswarm", componentType: "service", docsRef: "chart-docs"}
+	err := processCharts(context.Background(), registry, repositories, opts, 2, func(result chartResult) error {
+		messages = append(messages, result.messages[0])
+		if result.component != nil {
+			names = append(names, result.component.Name)
+		}
+		if result.readme != nil {
+			readmes = append(readmes, string(result.readme))
+			if got := result.component.Annotations["backstage.io/techdocs-ref"]; got != "dir:chart-docs/"+result.component.Name {
+				t.Errorf("processCharts() techdocs-ref = %q, want docs directory", got)
+			}
+		}
+		if result.api != nil {
+			apis = append(apis, result.api.Name)
+			if diff := cmp.Diff([]string{result.api.Name}, result.component.ProvidesAPIs); diff != "" {
+				t.Errorf("processCharts() providesApis mismatch (-want +got):\n%s", diff)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("processCharts() unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"a-app", "c-app", "d-app"}, names); diff != "" {
+		t.Errorf("processCharts() components mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"a-app-values"}, apis); diff != "" {
+		t.Errorf("processCharts() APIs mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"content of README.md"}, readmes); diff != "" {
+		t.Errorf("processCharts() READMEs mismatch (-want +got):\n%s", diff)
+	}
+	var wantMessages []string
+	for _, repo := range repositories {
+		wantMessages = append(wantMessages, fmt.Sprintf("Processing repository: %s", repo))
+	}
+	if diff := cmp.Diff(wantMessages, messages); diff != "" {
+		t.Errorf("processCharts() messages mismatch (-want +got):\n%s", diff)
+	}
+	if registry.maxInFlight != 2 {
+		t.Errorf("processCharts() made up to %d concurrent requests, want 2", registry.maxInFlight)
+	}
+}
+
+func TestProcessChartsYieldError(t *testing.T) {
+	yieldErr := errors.New("yield failed")
+	calls := 0
+	err := processCharts(context.Background(), &fakeRegistry{}, []string{"charts/a-app", "charts/c-app"}, chartOptions{}, 2, func(result chartResult) error {
+		calls++
+		return yieldErr
+	})
+	if !errors.Is(err, yieldErr) {
+		t.Errorf("processCharts() error = %v, want %v", err, yieldErr)
+	}
+	if calls != 1 {
+		t.Errorf("processCharts() called yield %d times, want 1", calls)
+	}
+}
+
+func TestCreateValuesSchemaAPI(t *testing.T) {
+	comp, err := component.New("hello-world-app", component.WithNamespace("giantswarm"), component.WithOwner("group:team-honeybadger"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		schema  string
+		want    *bscatalog.Entity
+		wantErr bool
+	}{
+		{
+			name:   "Minimal",
+			schema: `{"type": "object"}`,
+			want: &bscatalog.Entity{
+				APIVersion: bscatalog.APIVersion,
+				Kind:       bscatalog.EntityKindAPI,
+				Metadata: bscatalog.EntityMetadata{
+					Name:        "hello-world-app-values",
+					Namespace:   "giantswarm",
+					Title:       "hello-world-app values",
+					Description: "Configuration values of the hello-world-app Helm chart",
+					Tags:        []string{"helm", "helmchart"},
+				},
+				Spec: bscatalog.APISpec{
+					Type:       "helm-values",
+					Lifecycle:  "production",
+					Owner:      "group:team-honeybadger",
+					Definition: `{"type": "object"}`,
+				},
+			},
+		},
+		{
+			name:   "TitleAndDescription",
+			schema: `{"title": "Hello world", "description": "Values of the hello world app", "type": "object"}`,
+			want: &bscatalog.Entity{
+				APIVersion: bscatalog.APIVersion,
+				Kind:       bscatalog.EntityKindAPI,
+				Metadata: bscatalog.EntityMetadata{
+					Name:        "hello-world-app-values",
+					Namespace:   "giantswarm",
+					Title:       "Hello world",
+					Description: "Values of the hello world app",
+					Tags:        []string{"helm", "helmchart"},
+				},
+				Spec: bscatalog.APISpec{
+					Type:       "helm-values",
+					Lifecycle:  "production",
+					Owner:      "group:team-honeybadger",
+					Definition: `{"title": "Hello world", "description": "Values of the hello world app", "type": "object"}`,
+				},
+			},
+		},
+		{
+			name:    "InvalidJSON",
+			schema:  `{"type":`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := createValuesSchemaAPI(comp, []byte(tt.schema))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("createValuesSchemaAPI() expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("createValuesSchemaAPI() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got.ToEntity()); diff != "" {
+				t.Errorf("createValuesSchemaAPI() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteChartDocs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "chart-docs", "hello-world-app")
+	err := writeChartDocs(dir, "hello-world-app", []byte("# Hello world\n"))
+	if err != nil {
+		t.Fatalf("writeChartDocs() unexpected error: %v", err)
+	}
+
+	for file, want := range map[string]string{
+		"mkdocs.yml":                      "site_name: \"hello-world-app\"\nnav:\n  - Home: index.md\nplugins:\n  - techdocs-core\n",
+		filepath.Join("docs", "index.md"): "# Hello world\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("could not read %s: %v", file, err)
+		}
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Errorf("writeChartDocs() %s mismatch (-want +got):\n%s", file, diff)
+		}
+	}
+}
+
+func TestIsPathSegment(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "hello-world-app", want: true},
+		{name: "", want: false},
+		{name: ".", want: false},
+		{name: "..", want: false},
+		{name: "a/b", want: false},
+		{name: "../x", want: false},
+		{name: `a\b`, want: false},
+	}
+	for _, tt := range tests {
+		if got := isPathSegment(tt.name); got != tt.want {
+			t.Errorf("isPathSegment(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestChartDocsPublishDuplicate(t *testing.T) {
+	host, err := githubhost.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	docs := &chartDocs{dir: dir, host: host, repositories: make(map[string]string)}
+
+	var comps []*component.Component
+	for _, repo := range []string{"charts/hello-world-app", "other/hello-world-app"} {
+		comp, err := component.New("hello-world-app", component.WithGithubProjectSlug("giantswarm/hello-world-app"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		comp.SetAnnotation("backstage.io/techdocs-ref", "dir:chart-docs/hello-world-app")
+		err = docs.publish(chartResult{repository: repo, component: comp, readme: []byte("README of " + repo)})
+		if err != nil {
+			t.Fatalf("publish() unexpected error: %v", err)
+		}
+		comps = append(comps, comp)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "hello-world-app", "docs", "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("README of charts/hello-world-app", string(got)); diff != "" {
+		t.Errorf("publish() README mismatch (-want +got):\n%s", diff)
+	}
+	if got := comps[0].Annotations["backstage.io/techdocs-ref"]; got != "dir:chart-docs/hello-world-app" {
+		t.Errorf("publish() first techdocs-ref = %q, want docs directory", got)
+	}
+	if got, want := comps[1].Annotations["backstage.io/techdocs-ref"], "url:https://github.com/giantswarm/hello-world-app/tree/main"; got != want {
+		t.Errorf("publish() duplicate techdocs-ref = %q, want %q", got, want)
+	}
+}
+
+// nestedNameRegistry serves charts with a home URL resolving to a component
+// name that is not a single path segment.
+type nestedNameRegistry struct {
+	*fakeRegistry
+}
+
+func (r nestedNameRegistry) GetRepositoryManifest(ctx context.Context, repo, tag string) (*ociregistry.ManifestInfo, error) {
+	info, err := r.fakeRegistry.GetRepositoryManifest(ctx, repo, tag)
+	if err != nil {
+		return nil, err
+	}
+	info.Config["home"] = "https://github.com/giantswarm/a-app/charts"
+	return info, nil
+}
+
+func TestProcessChartNestedName(t *testing.T) {
+	host, err := githubhost.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := chartOptions{namespace: "giantswarm", componentType: "service", host: host, docsRef: "chart-docs"}
+	result := processChart(context.Background(), nestedNameRegistry{&fakeRegistry{}}, "charts/a-app", opts)
+	if result.component == nil {
+		t.Fatalf("processChart() returned no component, messages %v", result.messages)
+	}
+	if result.readme != nil {
+		t.Errorf("processChart() returned README for component %q", result.component.Name)
+	}
+	if got := result.component.Annotations["backstage.io/techdocs-ref"]; !strings.HasPrefix(got, "url:") {
+		t.Errorf("processChart() techdocs-ref = %q, want repository URL", got)
+	}
+}
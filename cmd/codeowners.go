@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/codeowners"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/repositories"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/component"
+)
+
+// Annotation listing the GitHub teams found in the CODEOWNERS file.
+const codeOwnersAnnotation = "giantswarm.io/codeowners"
+
+// setCodeOwners adds the teams of the organization found in the CODEOWNERS file of the
+// component's repository, if there is one, as an annotation.
+func setCodeOwners(repoService *repositories.Service, organization string, c *component.Component) {
+	path, data, err := repoService.LoadCodeOwnersFile(c.Name)
+	if repositories.IsFileNotFoundError(err) {
+		log.Printf("DEBUG - %s - no CODEOWNERS file found", c.Name)
+		return
+	} else if err != nil {
+		log.Printf("WARN - %s - error fetching CODEOWNERS file: %v", c.Name, err)
+		return
+	}
+
+	teams := codeowners.Teams(data, organization)
+	if len(teams) == 0 {
+		log.Printf("DEBUG - %s - no teams found in %s", c.Name, path)
+	}
+
+	// An empty value documents that the file exists, but names no teams.
+	c.SetAnnotation(codeOwnersAnnotation, strings.Join(teams, ","))
+}
+
+// codeOwnerMismatches returns the names of the components whose owner team
+// is not among the teams in their repository's CODEOWNERS file, logging
+// each of them. Components without CODEOWNERS file are not included.
+func codeOwnerMismatches(components []*component.Component) []string {
+	var mismatches []string
+	for _, c := range components {
+		value, ok := c.Annotations[codeOwnersAnnotation]
+		if !ok {
+			continue
+		}
+		if slices.Contains(strings.Split(value, ","), c.Owner) {
+			continue
+		}
+		mismatches = append(mismatches, c.Name)
+		log.Printf("WARN - %s - owner team %q is not in CODEOWNERS (teams: %q)", c.Name, c.Owner, value)
+	}
+
+	return mismatches
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/component"
+)
+
+func TestCodeOwnerMismatches(t *testing.T) {
+	newComponent := func(name, owner string, codeOwners *string) *component.Component {
+		c, err := component.New(name, component.WithOwner(owner))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if codeOwners != nil {
+			c.SetAnnotation(codeOwnersAnnotation, *codeOwners)
+		}
+		return c
+	}
+	teams := func(s string) *string { return &s }
+
+	components := []*component.Component{
+		newComponent("matching", "team-a", teams("team-a,team-b")),
+		newComponent("other-team", "team-a", teams("team-b")),
+		newComponent("no-teams", "team-a", teams("")),
+		newComponent("no-codeowners", "team-a", nil),
+	}
+
+	want := []string{"other-team", "no-teams"}
+	if diff := cmp.Diff(want, codeOwnerMismatches(components)); diff != "" {
+		t.Errorf("codeOwnerMismatches() mismatch (-want +got):\n%s", diff)
+	}
+}
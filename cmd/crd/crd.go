@@ -2,12 +2,18 @@
 package crd
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
+	"github.com/google/go-github/v90/github"
 	"github.com/spf13/cobra"
 
+	"github.com/giantswarm/backstage-catalog-importer/pkg/config"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/input/crdconfig"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/input/githuburl"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/api"
@@ -16,7 +22,7 @@ import (
 
 // Command is the crd cobra command.
 var Command = &cobra.Command{
-	Use:   "crd <config-file>",
+	Use:   "crd [config-file]",
 	Short: "Export CRDs as Backstage API entities",
 	Long: `The command reads a YAML config file with CRD definitions and generates Backstage API entities.
 
@@ -35,70 +41,122 @@ Example config:
 Use "-" as the config file path to read from stdin.
 
 Arguments:
-  config-file    Path to YAML config file, or "-" for stdin`,
-	Args: cobra.ExactArgs(1),
-	Run:  runCRD,
+  config-file    Path to YAML config file, or "-" for stdin. Can be omitted
+                 if set in the configuration file given via --config.`,
+	Args:          cobra.RangeArgs(0, 1),
+	RunE:          runCRD,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
 func init() {
-	Command.PersistentFlags().StringP("namespace", "n", "default", "Backstage namespace for the API entities")
+	Command.PersistentFlags().StringP("namespace", "n", config.DefaultNamespace, "Backstage namespace for the API entities")
 }
 
-func runCRD(cmd *cobra.Command, args []string) {
-	configPath := args[0]
+func runCRD(cmd *cobra.Command, args []string) error {
+	cfg, err := config.FromFlags(cmd.Root().PersistentFlags())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	settings := *cfg.CRD
+	if len(args) > 0 {
+		settings.Config = args[0]
+	}
+	if settings.Config == "" {
+		return errors.New("no CRD config file given, neither as argument nor in the configuration file")
+	}
+
+	settings.Namespace, err = config.String(cmd.PersistentFlags(), "namespace", settings.Namespace)
+	if err != nil {
+		return err
+	}
+
+	outputPath, err := config.String(cmd.Root().PersistentFlags(), "output", cfg.Output)
+	if err != nil {
+		return err
+	}
 
-	namespace, err := cmd.PersistentFlags().GetString("namespace")
+	creds, err := httpclient.CredentialsFromEnv()
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	if creds.IsZero() {
+		log.Println("WARN: No GitHub credentials set. Using unauthenticated requests (lower rate limits).")
 	}
 
-	outputPath, err := cmd.Root().PersistentFlags().GetString("output")
+	clientOptions, err := config.GitHubClientOptions(cmd.Root().PersistentFlags(), cfg)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	quota := httpclient.NewQuota()
+	client, err := httpclient.NewGitHubClient(creds.Token, append(clientOptions, httpclient.WithAppCredentials(creds.App), httpclient.WithQuota(quota))...)
+	if err != nil {
+		return fmt.Errorf("could not create GitHub client: %w", err)
+	}
+	defer log.Printf("GitHub API quota used: %s", quota)
+
+	host, err := config.GitHubHost(cmd.Root().PersistentFlags(), cfg)
+	if err != nil {
+		return err
+	}
+
+	summary, err := Export(client, host, settings, outputPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s\n", summary)
+
+	return nil
+}
 
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		log.Println("WARN: GITHUB_TOKEN not set. Using unauthenticated requests (lower rate limits).")
+// Export exports the CRDs listed in the CRD config file given in settings as
+// API entities to a file in the path directory. A config file path of "-"
+// reads from stdin.
+func Export(client *github.Client, host githubhost.Host, settings config.CRD, path string) (export.Summary, error) {
+	if settings.Config == "" {
+		return export.Summary{}, errors.New("no CRD config file configured")
 	}
 
 	// Create config service
 	var configService *crdconfig.Service
-	if configPath == "-" {
+	var err error
+	if settings.Config == "-" {
 		// Read from stdin
 		configService, err = crdconfig.New(crdconfig.Config{
 			Reader: os.Stdin,
 		})
 	} else {
 		configService, err = crdconfig.New(crdconfig.Config{
-			FilePath: configPath,
+			FilePath: settings.Config,
 		})
 	}
 	if err != nil {
-		log.Fatalf("Failed to create config service: %v", err)
+		return export.Summary{}, fmt.Errorf("failed to create config service: %w", err)
 	}
 
 	// Load config
 	items, err := configService.Load()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		return export.Summary{}, fmt.Errorf("failed to load config: %w", err)
 	}
 
 	log.Printf("Found %d CRD definitions in config", len(items))
 
 	// Create GitHub service
 	githubService, err := githuburl.New(githuburl.Config{
-		AuthToken: token,
+		Client: client,
+		Host:   host,
 	})
 	if err != nil {
-		log.Fatalf("Failed to create GitHub service: %v", err)
+		return export.Summary{}, fmt.Errorf("failed to create GitHub service: %w", err)
 	}
 
 	// Create exporter
-	apiExporter := export.New(export.Config{TargetPath: outputPath + "/crds.yaml"})
+	apiExporter := export.New(export.Config{TargetPath: filepath.Join(path, settings.OutputFile)})
 
 	// Process each CRD
-	numAPIs := 0
 	for i, item := range items {
 		log.Printf("[%d/%d] Processing CRD from: %s", i+1, len(items), item.URL)
 
@@ -125,7 +183,7 @@ func runCRD(cmd *cobra.Command, args []string) {
 		// Create API entity
 		apiEntity, err := api.New(
 			crdMeta.Name,
-			api.WithNamespace(namespace),
+			api.WithNamespace(settings.Namespace),
 			api.WithTitle(crdMeta.Kind),
 			api.WithDescription(description),
 			api.WithOwner(item.Owner),
@@ -150,18 +208,16 @@ func runCRD(cmd *cobra.Command, args []string) {
 
 		entity := apiEntity.ToEntity()
 		if err := apiExporter.AddEntity(entity); err != nil {
-			log.Fatalf("Error adding API entity: %v", err)
+			return export.Summary{}, fmt.Errorf("error adding API entity: %w", err)
 		}
 
-		numAPIs++
 		log.Printf("Created API entity: %s", crdMeta.Name)
 	}
 
 	// Write file
 	if err := apiExporter.WriteFile(); err != nil {
-		log.Fatalf("Error writing APIs file: %v", err)
+		return export.Summary{}, fmt.Errorf("error writing APIs file: %w", err)
 	}
 
-	fmt.Printf("\n%d API entities written to file %s with size %d bytes\n",
-		numAPIs, apiExporter.TargetPath, apiExporter.Len())
+	return apiExporter.Summary("API entities"), nil
 }
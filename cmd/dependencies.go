@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/component"
+)
+
+// chartDependencyResolver maps Helm chart dependencies to the names of the
+// components providing the charts.
+type chartDependencyResolver struct {
+	// URL prefixes of chart repositories publishing our charts.
+	repositories []string
+
+	// Chart name -> name of the component providing the chart.
+	componentByChart map[string]string
+
+	// Names of all components.
+	components map[string]bool
+}
+
+func newChartDependencyResolver(components []*component.Component, repositories []string) *chartDependencyResolver {
+	r := &chartDependencyResolver{
+		componentByChart: make(map[string]string),
+		components:       make(map[string]bool, len(components)),
+	}
+
+	for _, repo := range repositories {
+		r.repositories = append(r.repositories, strings.TrimSuffix(repo, "/"))
+	}
+
+	for _, c := range components {
+		r.components[c.Name] = true
+		for _, chart := range c.HelmCharts {
+			if other, exists := r.componentByChart[chart.Name]; exists && other != c.Name {
+				log.Printf("WARN - chart %q is provided by components %q and %q, using %q", chart.Name, other, c.Name, other)
+				continue
+			}
+			r.componentByChart[chart.Name] = c.Name
+		}
+	}
+
+	return r
+}
+
+// resolve returns the names of the components that the given component
+// depends on, based on the dependencies of its Helm charts. Dependencies
+// from repositories other than ours are ignored, as are dependencies on the
+// component's own charts. The result is sorted.
+func (r *chartDependencyResolver) resolve(c *component.Component) []string {
+	var result []string
+
+	for _, chart := range c.HelmCharts {
+		for _, dep := range chart.Dependencies {
+			if dep == nil || !r.isOurRepository(dep.Repository) {
+				continue
+			}
+
+			name, ok := r.componentName(dep.Name)
+			if !ok {
+				log.Printf("WARN - %s - could not resolve dependency on chart %q from %s to a component", c.Name, dep.Name, dep.Repository)
+				continue
+			}
+			if name == c.Name || slices.Contains(result, name) {
+				continue
+			}
+
+			result = append(result, name)
+		}
+	}
+
+	slices.Sort(result)
+
+	return result
+}
+
+// componentName returns the name of the component providing the chart.
+// Charts not found in any component are matched against component names
+// directly, also considering the common "-app" suffix.
+func (r *chartDependencyResolver) componentName(chartName string) (string, bool) {
+	if name, ok := r.componentByChart[chartName]; ok {
+		return name, true
+	}
+	if r.components[chartName] {
+		return chartName, true
+	}
+	if r.components[chartName+"-app"] {
+		return chartName + "-app", true
+	}
+	return "", false
+}
+
+// isOurRepository reports whether the chart repository URL belongs to one
+// of the configured chart repositories.
+func (r *chartDependencyResolver) isOurRepository(url string) bool {
+	url = strings.TrimSuffix(url, "/")
+	for _, repo := range r.repositories {
+		if url == repo || strings.HasPrefix(url, repo+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// goModuleDependencies returns the names of the repositories that the Go
+// module defined by the given go.mod content requires directly. Only
+// modules hosted in our GitHub organization on the given host with a
+// repository in repoNames are considered. The result is sorted.
+func goModuleDependencies(host githubhost.Host, organization, repoName, data string, repoNames map[string]bool) ([]string, error) {
+	file, err := modfile.ParseLax("go.mod", []byte(data), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%s/%s/", host.Name(), organization)
+
+	var result []string
+	for _, req := range file.Require {
+		if req.Indirect {
+			continue
+		}
+
+		path, found := strings.CutPrefix(req.Mod.Path, prefix)
+		if !found {
+			continue
+		}
+
+		// The repository name is the first path element, followed by
+		// an optional major version suffix or module sub path.
+		name, _, _ := strings.Cut(path, "/")
+		if name == repoName || !repoNames[name] || slices.Contains(result, name) {
+			continue
+		}
+
+		result = append(result, name)
+	}
+
+	slices.Sort(result)
+
+	return result, nil
+}
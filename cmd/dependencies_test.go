@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/helmchart"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/component"
+)
+
+func TestChartDependencyResolver(t *testing.T) {
+	newComponent := func(name string, charts ...*helmchart.Chart) *component.Component {
+		c, err := component.New(name, component.WithHelmCharts(charts...))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return c
+	}
+	newChart := func(name string, deps ...*chart.Dependency) *helmchart.Chart {
+		c := &helmchart.Chart{}
+		c.Name = name
+		c.Dependencies = deps
+		return c
+	}
+
+	cluster := newComponent("cluster", newChart("cluster-base"))
+	dns := newComponent("external-dns-app")
+	observability := newComponent("observability-bundle", newChart("observability-bundle"))
+	bundle := newComponent("default-apps",
+		newChart("default-apps",
+			// Resolved via the chart index.
+			&chart.Dependency{Name: "cluster-base", Repository: "oci://gsoci.azurecr.io/charts/giantswarm"},
+			// Resolved via the "-app" suffix.
+			&chart.Dependency{Name: "external-dns", Repository: "https://giantswarm.github.io/default-catalog/"},
+			// Resolved via the component name, listed twice.
+			&chart.Dependency{Name: "observability-bundle", Repository: "oci://gsoci.azurecr.io/charts/giantswarm/"},
+			&chart.Dependency{Name: "observability-bundle", Repository: "oci://gsociprivate.azurecr.io/charts/giantswarm"},
+			// Foreign repository.
+			&chart.Dependency{Name: "redis", Repository: "https://charts.bitnami.com/bitnami"},
+			// Local subchart.
+			&chart.Dependency{Name: "default-apps", Repository: "file://../default-apps"},
+			// Unknown chart.
+			&chart.Dependency{Name: "unknown", Repository: "oci://gsoci.azurecr.io/charts/giantswarm"},
+		),
+		newChart("default-apps-extra",
+			// Self reference.
+			&chart.Dependency{Name: "default-apps", Repository: "oci://gsoci.azurecr.io/charts/giantswarm"},
+		),
+	)
+
+	opts := componentOptions{
+		repoPrefix:         "charts/giantswarm",
+		publicOciRegistry:  "gsoci.azurecr.io",
+		privateOciRegistry: "gsociprivate.azurecr.io",
+		chartRepositories:  []string{"https://giantswarm.github.io/"},
+	}
+	resolver := newChartDependencyResolver([]*component.Component{cluster, dns, observability, bundle}, opts.chartDependencyRepositories())
+
+	want := []string{"cluster", "external-dns-app", "observability-bundle"}
+	if diff := cmp.Diff(want, resolver.resolve(bundle)); diff != "" {
+		t.Errorf("resolve() mismatch (-want +got):\n%s", diff)
+	}
+
+	if got := resolver.resolve(cluster); got != nil {
+		t.Errorf("resolve() = %v, want nil", got)
+	}
+}
+
+func TestGoModuleDependencies(t *testing.T) {
+	data := `module github.com/giantswarm/app-operator/v7
+
+go 1.26
+
+require (
+	github.com/giantswarm/app-operator/v7 v7.0.0
+	github.com/giantswarm/apiextensions-application v0.6.2
+	github.com/giantswarm/k8sclient/v8 v8.0.0
+	github.com/giantswarm/microerror v0.4.1
+	github.com/giantswarm/microkit v1.0.0
+	github.com/giantswarm/not-in-lists v1.0.0
+	github.com/spf13/cobra v1.10.1
+)
+
+require github.com/giantswarm/micrologger v1.1.2 // indirect
+`
+	repoNames := map[string]bool{
+		"app-operator":              true,
+		"apiextensions-application": true,
+		"k8sclient":                 true,
+		"microerror":                true,
+		"microkit":                  true,
+		"micrologger":               true,
+		"unrelated":                 true,
+	}
+
+	got, err := goModuleDependencies("", "giantswarm", "app-operator", data, repoNames)
+	if err != nil {
+		t.Fatalf("goModuleDependencies() unexpected error %v", err)
+	}
+
+	want := []string{"apiextensions-application", "k8sclient", "microerror", "microkit"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("goModuleDependencies() mismatch (-want +got):\n%s", diff)
+	}
+
+	// On GitHub Enterprise Server, modules are hosted there.
+	enterprise, err := githubhost.New("github.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enterpriseData := strings.ReplaceAll(data, "github.com/giantswarm/", "github.example.com/giantswarm/")
+	got, err = goModuleDependencies(enterprise, "giantswarm", "app-operator", enterpriseData, repoNames)
+	if err != nil {
+		t.Fatalf("goModuleDependencies() unexpected error %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("goModuleDependencies() on enterprise host mismatch (-want +got):\n%s", diff)
+	}
+	got, err = goModuleDependencies(enterprise, "giantswarm", "app-operator", data, repoNames)
+	if err != nil || got != nil {
+		t.Errorf("goModuleDependencies() on enterprise host with github.com modules = %v, %v, want nil, nil", got, err)
+	}
+
+	_, err = goModuleDependencies("", "giantswarm", "app-operator", "require (", repoNames)
+	if err == nil {
+		t.Error("goModuleDependencies() expected error for invalid go.mod")
+	}
+}
@@ -2,27 +2,26 @@
 package groups
 
 import (
+	"errors"
 	"fmt"
 	"log"
-	"os"
+	"path/filepath"
 
 	"github.com/google/go-github/v90/github"
 	"github.com/spf13/cobra"
 
+	"github.com/giantswarm/backstage-catalog-importer/pkg/config"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/input/teams"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/group"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/output/export"
 )
 
-// Name of the GitHub organization owning our teams and users.
-const githubOrganization = "giantswarm"
-
 const (
 	teamsFlag     = "teams"
 	parentFlag    = "parent"
 	namespaceFlag = "namespace"
-
-	defaultNamespace = "default"
 )
 
 var Command = &cobra.Command{
@@ -39,59 +38,137 @@ least one filter must be given:
             "employees").
 
 When both are given, a team is exported only if it is in the allowlist AND a
-descendant of the parent team.`,
-	RunE: run,
+descendant of the parent team.
+
+With a configuration file, teams of every organization with a groups section
+are exported, and the filters can be set there. Flags given explicitly take
+precedence and apply to all organizations.`,
+	RunE:          run,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
 func init() {
 	Command.Flags().StringSlice(teamsFlag, nil, "Allowlist of team slugs to export (comma-separated). Only these teams are exported.")
 	Command.Flags().String(parentFlag, "", `Only export teams that are descendants of this parent team slug (e.g. "employees").`)
-	Command.Flags().StringP(namespaceFlag, "n", defaultNamespace, "Backstage namespace for the exported groups. Set to an empty string to omit the namespace field.")
+	Command.Flags().StringP(namespaceFlag, "n", config.DefaultNamespace, "Backstage namespace for the exported groups. Set to an empty string to omit the namespace field.")
 }
 
 func run(cmd *cobra.Command, args []string) error {
-	allowedTeams, err := cmd.Flags().GetStringSlice(teamsFlag)
+	cfg, err := config.FromFlags(cmd.Root().PersistentFlags())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	path, err := config.String(cmd.Root().PersistentFlags(), "output", cfg.Output)
+	if err != nil {
+		return fmt.Errorf("could not access 'output' flag: %w", err)
+	}
+
+	creds, err := httpclient.CredentialsFromEnv()
 	if err != nil {
 		return err
 	}
-	parent, err := cmd.Flags().GetString(parentFlag)
+	if creds.IsZero() {
+		return errors.New("please set environment variable GITHUB_TOKEN to a personal GitHub access token (PAT), or configure a GitHub App")
+	}
+
+	clientOptions, err := config.GitHubClientOptions(cmd.Root().PersistentFlags(), cfg)
 	if err != nil {
 		return err
 	}
-	namespace, err := cmd.Flags().GetString(namespaceFlag)
+	quota := httpclient.NewQuota()
+	client, err := httpclient.NewGitHubClient(creds.Token, append(clientOptions, httpclient.WithAppCredentials(creds.App), httpclient.WithQuota(quota))...)
+	if err != nil {
+		return fmt.Errorf("could not create GitHub client: %w", err)
+	}
+	defer log.Printf("GitHub API quota used: %s", quota)
+
+	host, err := config.GitHubHost(cmd.Root().PersistentFlags(), cfg)
 	if err != nil {
 		return err
 	}
 
-	// Require an explicit filter to avoid accidentally exporting all teams,
-	// which may expose sensitive (e.g. customer) team names.
-	if len(allowedTeams) == 0 && parent == "" {
-		log.Fatalf("Error: refusing to export all teams. Specify --%s and/or --%s to select which teams to expose.", teamsFlag, parentFlag)
+	numOrgs := 0
+	for _, org := range cfg.Organizations {
+		if org.Groups == nil {
+			continue
+		}
+		numOrgs++
+
+		settings, err := settingsFromFlags(cmd, *org.Groups)
+		if err != nil {
+			return err
+		}
+
+		summary, err := Export(client, host, org.Name, settings, path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\n%s\n", summary)
 	}
 
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		log.Fatal("Please set environment variable GITHUB_TOKEN to a personal GitHub access token (PAT).")
+	if numOrgs == 0 {
+		return errors.New("no organization with groups configured")
 	}
 
-	path, err := cmd.Root().PersistentFlags().GetString("output")
+	return nil
+}
+
+// settingsFromFlags returns the groups export settings, with explicitly set
+// flags taking precedence over the configuration.
+func settingsFromFlags(cmd *cobra.Command, g config.Groups) (config.Groups, error) {
+	var err error
+	g.Teams, err = config.StringSlice(cmd.Flags(), teamsFlag, g.Teams)
 	if err != nil {
-		log.Fatalf("Error: could not access 'output' flag - %s", err)
+		return g, err
+	}
+	g.Parent, err = config.String(cmd.Flags(), parentFlag, g.Parent)
+	if err != nil {
+		return g, err
+	}
+
+	// The namespace may be set to an empty string deliberately.
+	if cmd.Flags().Changed(namespaceFlag) || g.Namespace == nil {
+		namespace, err := cmd.Flags().GetString(namespaceFlag)
+		if err != nil {
+			return g, err
+		}
+		g.Namespace = &namespace
+	}
+
+	return g, nil
+}
+
+// Export exports the teams of one organization selected by the settings as
+// groups to a file in the path directory.
+func Export(client *github.Client, host githubhost.Host, organization string, settings config.Groups, path string) (export.Summary, error) {
+	// Require an explicit filter to avoid accidentally exporting all teams,
+	// which may expose sensitive (e.g. customer) team names.
+	if len(settings.Teams) == 0 && settings.Parent == "" {
+		return export.Summary{}, fmt.Errorf("refusing to export all teams of organization %q. Specify --%s and/or --%s to select which teams to expose", organization, teamsFlag, parentFlag)
+	}
+
+	namespace := config.DefaultNamespace
+	if settings.Namespace != nil {
+		namespace = *settings.Namespace
 	}
 
 	teamsService, err := teams.New(teams.Config{
-		GithubOrganization: githubOrganization,
-		GithubAuthToken:    token,
+		GithubOrganization: organization,
+		GithubClient:       client,
+		GithubHost:         host,
 	})
 	if err != nil {
-		log.Fatalf("Error: could not create teams service -- %v", err)
+		return export.Summary{}, fmt.Errorf("could not create teams service -- %w", err)
 	}
 
 	teamsList, err := teamsService.GetAll()
 	if err != nil {
-		log.Fatalf("Error: %v", err)
+		return export.Summary{}, err
 	}
-	log.Printf("Found %d teams in total", len(teamsList))
+	log.Printf("Found %d teams in total in organization %q", len(teamsList), organization)
 
 	// Map of team slug -> parent slug, used to resolve team ancestry.
 	parentBySlug := make(map[string]string, len(teamsList))
@@ -99,14 +176,13 @@ func run(cmd *cobra.Command, args []string) error {
 		parentBySlug[t.GetSlug()] = t.GetParent().GetSlug()
 	}
 
-	allowSet := make(map[string]bool, len(allowedTeams))
-	for _, s := range allowedTeams {
+	allowSet := make(map[string]bool, len(settings.Teams))
+	for _, s := range settings.Teams {
 		allowSet[s] = true
 	}
 
-	groupExporter := export.New(export.Config{TargetPath: path + "/groups.yaml"})
+	groupExporter := export.New(export.Config{TargetPath: filepath.Join(path, settings.OutputFile)})
 
-	numGroups := 0
 	exported := make(map[string]bool)
 	for _, team := range teamsList {
 		slug := team.GetSlug()
@@ -114,13 +190,13 @@ func run(cmd *cobra.Command, args []string) error {
 		if len(allowSet) > 0 && !allowSet[slug] {
 			continue
 		}
-		if parent != "" && !isDescendant(slug, parent, parentBySlug) {
+		if settings.Parent != "" && !isDescendant(slug, settings.Parent, parentBySlug) {
 			continue
 		}
 
 		members, err := teamsService.GetMembers(slug)
 		if err != nil {
-			log.Fatalf("Error: %v", err)
+			return export.Summary{}, err
 		}
 
 		var memberNames []string
@@ -128,22 +204,21 @@ func run(cmd *cobra.Command, args []string) error {
 			memberNames = append(memberNames, u.GetLogin())
 		}
 
-		g, err := groupFromTeam(team, memberNames, namespace)
+		g, err := groupFromTeam(host, team, memberNames, namespace)
 		if err != nil {
-			log.Fatalf("Error: could not create group -- %v", err)
+			return export.Summary{}, fmt.Errorf("could not create group -- %w", err)
 		}
 
 		err = groupExporter.AddEntity(g.ToEntity())
 		if err != nil {
-			log.Fatalf("Error: %v", err)
+			return export.Summary{}, err
 		}
 		exported[slug] = true
-		numGroups++
 	}
 
 	// Warn about allowlisted teams that were not exported, which usually means a
 	// typo in the slug or exclusion by the --parent filter.
-	for _, s := range allowedTeams {
+	for _, s := range settings.Teams {
 		if !exported[s] {
 			log.Printf("WARN: allowlisted team %q was not exported (not found or excluded by --%s)", s, parentFlag)
 		}
@@ -151,12 +226,10 @@ func run(cmd *cobra.Command, args []string) error {
 
 	err = groupExporter.WriteFile()
 	if err != nil {
-		log.Fatalf("Error writing groups: %v", err)
+		return export.Summary{}, fmt.Errorf("could not write groups -- %w", err)
 	}
 
-	fmt.Printf("\n%d groups written to file %s with size %d bytes\n", numGroups, groupExporter.TargetPath, groupExporter.Len())
-
-	return nil
+	return groupExporter.Summary("groups"), nil
 }
 
 // isDescendant reports whether the team identified by slug is a (transitive)
@@ -179,7 +252,7 @@ func isDescendant(slug, ancestor string, parentBySlug map[string]string) bool {
 
 // groupFromTeam builds a Backstage group from a GitHub team and its member
 // logins. An empty namespace omits the namespace field from the entity.
-func groupFromTeam(team *github.Team, memberNames []string, namespace string) (*group.Group, error) {
+func groupFromTeam(host githubhost.Host, team *github.Team, memberNames []string, namespace string) (*group.Group, error) {
 	parentTeamName := ""
 	if team.GetParent() != nil {
 		parentTeamName = team.GetParent().GetSlug()
@@ -189,7 +262,7 @@ func groupFromTeam(team *github.Team, memberNames []string, namespace string) (*
 		group.WithNamespace(namespace),
 		group.WithTitle(team.GetName()),
 		group.WithDescription(team.GetDescription()),
-		group.WithPictureURL(fmt.Sprintf("https://avatars.githubusercontent.com/t/%d?s=116&v=4", team.GetID())),
+		group.WithPictureURL(host.TeamAvatarURL(team.GetID(), 116)),
 		group.WithMemberNames(memberNames...),
 		group.WithParentName(parentTeamName),
 		group.WithGrafanaDashboardSelector(fmt.Sprintf("tags @> 'owner:%s'", team.GetSlug())),
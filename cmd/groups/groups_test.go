@@ -125,7 +125,7 @@ func TestGroupFromTeam(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			g, err := groupFromTeam(tc.team, tc.memberNames, tc.namespace)
+			g, err := groupFromTeam("", tc.team, tc.memberNames, tc.namespace)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
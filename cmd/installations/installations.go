@@ -2,12 +2,18 @@
 package installations
 
 import (
+	"errors"
 	"fmt"
 	"log"
-	"os"
+	"path/filepath"
 
+	"github.com/google/go-github/v90/github"
 	"github.com/spf13/cobra"
 
+	"github.com/giantswarm/backstage-catalog-importer/pkg/config"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/entityrules"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/input/installations"
 	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/resource"
@@ -17,82 +23,171 @@ import (
 var Command = &cobra.Command{
 	Use:   "installations",
 	Short: "Export installations catalog",
-	Long:  `Exports Giant Swarm installations for GS-internal use.`,
-	RunE:  run,
+	Long: `Exports Giant Swarm installations for GS-internal use.
+
+With a configuration file, installations of every organization with an
+installations section are exported. Use --org to export a single organization.`,
+	RunE:          run,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
 const (
 	orgFlag    = "org"
 	repoFlag   = "repo"
 	outputFlag = "output"
+	rulesFlag  = "rules"
 
 	awsCloudProviderURLMask = "https://signin.aws.amazon.com/switchrole?account=%s&roleName=%s&displayName=%s"
 
 	providerAWS = "aws"
 
-	iconAWS     = "aws"
-	iconGrafana = "grafana"
+	iconAWS = "aws"
 )
 
 func init() {
-	Command.Flags().String(orgFlag, "giantswarm", "GitHub organization to export users from")
-	Command.Flags().String(repoFlag, "installations", "Name of the repository containing installation data")
+	Command.Flags().String(orgFlag, config.DefaultOrganization, "GitHub organization to export installations from")
+	Command.Flags().String(repoFlag, config.DefaultInstallationsRepository, "Name of the repository containing installation data")
+	Command.Flags().String(rulesFlag, "", "Path to a YAML file with rules adding links and annotations to entities. If empty, built-in default rules are used")
 
-	Command.PersistentFlags().StringP(outputFlag, "o", ".", "Output directory path")
+	Command.PersistentFlags().StringP(outputFlag, "o", config.DefaultOutput, "Output directory path")
 }
 
 func run(cmd *cobra.Command, args []string) error {
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		log.Fatal("Please set environment variable GITHUB_TOKEN to a personal GitHub access token (PAT).")
+	creds, err := httpclient.CredentialsFromEnv()
+	if err != nil {
+		return err
+	}
+	if creds.IsZero() {
+		return errors.New("please set environment variable GITHUB_TOKEN to a personal GitHub access token (PAT), or configure a GitHub App")
 	}
 
-	org, err := cmd.Flags().GetString(orgFlag)
+	cfg, err := config.FromFlags(cmd.Root().PersistentFlags())
 	if err != nil {
-		log.Fatalf("Error: could not access '--org' flag - %s", err)
+		return fmt.Errorf("error loading config: %w", err)
 	}
-	repo, err := cmd.Flags().GetString(repoFlag)
+
+	path, err := config.String(cmd.PersistentFlags(), outputFlag, cfg.Output)
 	if err != nil {
-		log.Fatalf("Error: could not access '--repo' flag - %s", err)
+		return fmt.Errorf("could not access '--output' flag: %w", err)
 	}
-	path, err := cmd.PersistentFlags().GetString(outputFlag)
+
+	clientOptions, err := config.GitHubClientOptions(cmd.Root().PersistentFlags(), cfg)
 	if err != nil {
-		log.Fatalf("Error: could not access '--output' flag - %s", err)
+		return err
+	}
+	quota := httpclient.NewQuota()
+	client, err := httpclient.NewGitHubClient(creds.Token, append(clientOptions, httpclient.WithAppCredentials(creds.App), httpclient.WithQuota(quota))...)
+	if err != nil {
+		return fmt.Errorf("could not create GitHub client: %w", err)
+	}
+	defer log.Printf("GitHub API quota used: %s", quota)
+
+	host, err := config.GitHubHost(cmd.Root().PersistentFlags(), cfg)
+	if err != nil {
+		return err
+	}
+
+	orgs := cfg.Organizations
+	if cmd.Flags().Changed(orgFlag) {
+		org, err := cmd.Flags().GetString(orgFlag)
+		if err != nil {
+			return fmt.Errorf("could not access '--org' flag: %w", err)
+		}
+		// Export the given organization only, with defaults if not configured.
+		o := cfg.Organization(org)
+		if o == nil || o.Installations == nil {
+			o = &config.Organization{
+				Name: org,
+				Installations: &config.Installations{
+					Repository: config.DefaultInstallationsRepository,
+					OutputFile: config.DefaultInstallationsOutputFile,
+				},
+			}
+		}
+		orgs = []config.Organization{*o}
 	}
 
-	installationsExporter := export.New(export.Config{TargetPath: path + "/installations.yaml"})
+	numOrgs := 0
+	for _, org := range orgs {
+		if org.Installations == nil {
+			continue
+		}
+		numOrgs++
+
+		settings := *org.Installations
+		settings.Repository, err = config.String(cmd.Flags(), repoFlag, settings.Repository)
+		if err != nil {
+			return fmt.Errorf("could not access '--repo' flag: %w", err)
+		}
+		settings.Rules, err = config.String(cmd.Flags(), rulesFlag, settings.Rules)
+		if err != nil {
+			return fmt.Errorf("could not access '--rules' flag: %w", err)
+		}
+
+		summary, err := Export(client, host, org.Name, settings, path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\n%s\n", summary)
+	}
+
+	if numOrgs == 0 {
+		return errors.New("no organization with installations configured")
+	}
+
+	return nil
+}
+
+// Export exports the installations of one organization to a file in the
+// path directory.
+func Export(client *github.Client, host githubhost.Host, org string, settings config.Installations, path string) (export.Summary, error) {
+	rules, err := entityrules.LoadFile(settings.Rules)
+	if err != nil {
+		return export.Summary{}, fmt.Errorf("could not load rules -- %w", err)
+	}
+
+	installationsExporter := export.New(export.Config{TargetPath: filepath.Join(path, settings.OutputFile)})
 
 	insService, err := installations.New(installations.Config{
 		GithubOrganization:   org,
-		GithubRepositoryName: repo,
-		GithubAuthToken:      token,
+		GithubRepositoryName: settings.Repository,
+		GithubClient:         client,
+		GithubHost:           host,
 	})
 	if err != nil {
-		log.Fatalf("Error: could not create service -- %v", err)
+		return export.Summary{}, fmt.Errorf("could not create service -- %w", err)
 	}
 
 	ins, err := insService.GetInstallations()
 	if err != nil {
-		log.Fatalf("Error: could not write installations -- %v", err)
+		return export.Summary{}, fmt.Errorf("could not read installations -- %w", err)
 	}
 
 	for _, installation := range ins {
-		e := toResourceEntity(installation)
+		e, err := toResourceEntity(host, org, settings.Repository, rules, installation)
+		if err != nil {
+			return export.Summary{}, fmt.Errorf("could not apply rules to installation %s -- %w", installation.Codename, err)
+		}
 		err = installationsExporter.AddEntity(e)
 		if err != nil {
-			log.Fatalf("Error: could add installation resource -- %v", err)
+			return export.Summary{}, fmt.Errorf("could add installation resource -- %w", err)
 		}
 	}
 
 	err = installationsExporter.WriteFile()
 	if err != nil {
-		log.Fatalf("Error: could not write installations -- %v", err)
+		return export.Summary{}, fmt.Errorf("could not write installations -- %w", err)
 	}
 
-	return nil
+	return installationsExporter.Summary("installations"), nil
 }
 
-func toResourceEntity(ins *installations.Installation) *bscatalog.Entity {
+// toResourceEntity converts an installation read from the given repository
+// of the organization into a resource entity. Links added by the rules come
+// after the CMC and CCR links and before the AWS Console links.
+func toResourceEntity(host githubhost.Host, org, repository string, rules *entityrules.Rules, ins *installations.Installation) (*bscatalog.Entity, error) {
 	r := resource.Resource{
 		Name:        ins.Codename,
 		Title:       ins.Codename,
@@ -105,17 +200,17 @@ func toResourceEntity(ins *installations.Installation) *bscatalog.Entity {
 			"giantswarm.io/pipeline": ins.Pipeline,
 		},
 		Annotations: map[string]string{
-			"backstage.io/source-location": fmt.Sprintf("url:https://github.com/giantswarm/installations/blob/master/%s/cluster.yaml", ins.Codename),
+			"backstage.io/source-location": "url:" + host.BlobURL(org+"/"+repository, "master", ins.Codename+"/cluster.yaml"),
 		},
 		Links: []bscatalog.EntityLink{
 			{
-				URL:   fmt.Sprintf("https://github.com/giantswarm/%s", ins.CmcRepository),
+				URL:   host.RepoURL(org + "/" + ins.CmcRepository),
 				Title: "Customer management clusters (CMC)",
 				Icon:  "github",
 				Type:  "CMC",
 			},
 			{
-				URL:   fmt.Sprintf("https://github.com/giantswarm/%s", ins.CcrRepository),
+				URL:   host.RepoURL(org + "/" + ins.CcrRepository),
 				Title: "Customer config (CCR)",
 				Icon:  "github",
 				Type:  "CCR",
@@ -153,53 +248,23 @@ func toResourceEntity(ins *installations.Installation) *bscatalog.Entity {
 		r.Annotations["giantswarm.io/escalation-matrix"] = ins.EscalationMatrix
 	}
 
-	// Happa and Grafana link
-	if ins.Provider == providerAWS || ins.Provider == "azure" || ins.Provider == "kvm" {
-		// Vintage
-		r.Links = append(r.Links, []bscatalog.EntityLink{
-			{
-				URL:   fmt.Sprintf("https://happa.g8s.%s/admin-login", ins.Base),
-				Title: "Happa",
-				Icon:  "giantswarm",
-			}, {
-				URL:   fmt.Sprintf("https://grafana.g8s.%s/", ins.Base),
-				Title: "Grafana",
-				Icon:  iconGrafana,
-			},
-		}...)
-	} else {
-		r.Links = append(r.Links, []bscatalog.EntityLink{
-			{
-				URL:   fmt.Sprintf("https://grafana-%s.teleport.giantswarm.io", ins.Codename),
-				Title: "Grafana (via Teleport)",
-				Icon:  iconGrafana,
-			}, {
-				URL:   fmt.Sprintf("https://grafana.%s.%s/", ins.Codename, ins.Base),
-				Title: "Grafana (customer URL)",
-				Icon:  iconGrafana,
-			}, {
-				URL:   fmt.Sprintf("https://happa.%s.%s/admin-login", ins.Codename, ins.Base),
-				Title: "Happa",
-				Icon:  "giantswarm",
-			}, {
-				URL:   fmt.Sprintf("https://kyverno-%s.teleport.giantswarm.io", ins.Codename),
-				Title: "Policy Reporter (via Teleport)",
-				Icon:  "dashboard",
-			},
-		}...)
+	e := r.ToEntity()
+	err := rules.Apply(e)
+	if err != nil {
+		return nil, err
 	}
 
 	// AWS Console link
 	if ins.Aws != nil {
 		if ins.Aws.HostCluster.Account != "" && ins.Aws.HostCluster.AdminRoleARN != "" {
-			r.Links = append(r.Links, bscatalog.EntityLink{
+			e.Metadata.Links = append(e.Metadata.Links, bscatalog.EntityLink{
 				URL:   fmt.Sprintf(awsCloudProviderURLMask, ins.Aws.HostCluster.Account, ins.Aws.HostCluster.AdminRoleARN, fmt.Sprintf("%s+management+cluster", ins.Codename)),
 				Title: "AWS Console (management cluster)",
 				Icon:  iconAWS,
 			})
 		}
 		if ins.Aws.GuestCluster.Account != "" && ins.Aws.GuestCluster.AdminRoleARN != "" {
-			r.Links = append(r.Links, bscatalog.EntityLink{
+			e.Metadata.Links = append(e.Metadata.Links, bscatalog.EntityLink{
 				URL:   fmt.Sprintf(awsCloudProviderURLMask, ins.Aws.GuestCluster.Account, ins.Aws.GuestCluster.AdminRoleARN, fmt.Sprintf("%s+workload+clusters", ins.Codename)),
 				Title: "AWS Console (workload clusters)",
 				Icon:  iconAWS,
@@ -207,5 +272,5 @@ func toResourceEntity(ins *installations.Installation) *bscatalog.Entity {
 		}
 	}
 
-	return r.ToEntity()
+	return e, nil
 }
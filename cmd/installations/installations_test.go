@@ -0,0 +1,99 @@
+package installations
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/entityrules"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/installations"
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+)
+
+func TestToResourceEntity(t *testing.T) {
+	host, err := githubhost.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ins := &installations.Installation{
+		Codename:      "alpha",
+		Customer:      "acme",
+		CmcRepository: "acme-management-clusters",
+		CcrRepository: "acme-configs",
+		Pipeline:      "stable",
+		Provider:      "capa",
+	}
+
+	e, err := toResourceEntity(host, "example", "clusters", nil, ins)
+	if err != nil {
+		t.Fatalf("toResourceEntity() unexpected error %v", err)
+	}
+
+	wantLocation := "url:https://github.com/example/clusters/blob/master/alpha/cluster.yaml"
+	if got := e.Metadata.Annotations["backstage.io/source-location"]; got != wantLocation {
+		t.Errorf("source-location = %q, want %q", got, wantLocation)
+	}
+
+	wantLinks := []bscatalog.EntityLink{
+		{
+			URL:   "https://github.com/example/acme-management-clusters",
+			Title: "Customer management clusters (CMC)",
+			Icon:  "github",
+			Type:  "CMC",
+		},
+		{
+			URL:   "https://github.com/example/acme-configs",
+			Title: "Customer config (CCR)",
+			Icon:  "github",
+			Type:  "CCR",
+		},
+	}
+	if diff := cmp.Diff(wantLinks, e.Metadata.Links); diff != "" {
+		t.Errorf("links mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestToResourceEntityLinkOrder(t *testing.T) {
+	host, err := githubhost.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := entityrules.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ins := &installations.Installation{
+		Base:          "example.com",
+		Codename:      "beta",
+		Customer:      "acme",
+		CmcRepository: "acme-management-clusters",
+		CcrRepository: "acme-configs",
+		Provider:      "aws",
+		Aws: &installations.AwsDetails{
+			HostCluster: installations.AwsIdentity{Account: "123", AdminRoleARN: "admin"},
+		},
+	}
+
+	e, err := toResourceEntity(host, "giantswarm", "installations", rules, ins)
+	if err != nil {
+		t.Fatalf("toResourceEntity() unexpected error %v", err)
+	}
+
+	var got []string
+	for _, l := range e.Metadata.Links {
+		got = append(got, l.Title)
+	}
+	want := []string{
+		"Customer management clusters (CMC)",
+		"Customer config (CCR)",
+		"Happa",
+		"Grafana",
+		"AWS Console (management cluster)",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("link order mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -2,20 +2,30 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/google/go-github/v90/github"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/giantswarm/backstage-catalog-importer/cmd/charts"
 	"github.com/giantswarm/backstage-catalog-importer/cmd/crd"
 	groups "github.com/giantswarm/backstage-catalog-importer/cmd/groups"
 	installations "github.com/giantswarm/backstage-catalog-importer/cmd/installations"
 	users "github.com/giantswarm/backstage-catalog-importer/cmd/users"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/config"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/entityrules"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/input/helmchart"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/input/repositories"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/systemsconfig"
 	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/component"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/output/export"
@@ -25,34 +35,49 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "backstage-catalog-importer",
 	Short: "Giant Swarm tool to import data into backstage's catalog",
-	Run:   runRoot,
+	// Recording or replaying must start before any command creates clients.
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return config.RecordOrReplay(cmd.Root().PersistentFlags())
+	},
+	RunE:          runRoot,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
 const (
-	// Name of the GitHub organization owning our teams and users.
-	githubOrganization = "giantswarm"
-
-	// Name of the repository holding our repository meta data.
-	githubManagementRepository = "github"
-
-	// Directory path within githubManagementRepository holding repo metadata YAML files.
-	repositoriesPath = "repositories"
-
 	// Backstage annotation key for component icon URL.
 	iconBackstageAnnotation = "giantswarm.io/icon-url"
 )
 
 func init() {
-	rootCmd.PersistentFlags().StringP("output", "o", ".", "Output directory path")
-	rootCmd.Flags().StringP("chart-repo-prefix", "", "charts/giantswarm", "Prefix for chart repositories in the OCI registries")
-	rootCmd.Flags().StringP("public-oci-registry", "", "gsoci.azurecr.io", "Host name of the public OCI registry")
-	rootCmd.Flags().StringP("private-oci-registry", "", "gsociprivate.azurecr.io", "Host name of the private OCI registry")
-
+	rootCmd.PersistentFlags().StringP(config.FlagName, "c", "", "Path to a YAML configuration file for all commands. Flags given explicitly take precedence")
+	rootCmd.PersistentFlags().StringP("output", "o", config.DefaultOutput, "Output directory path")
+	rootCmd.PersistentFlags().String(config.CacheDirFlagName, "", "Directory of the on-disk cache of GitHub API responses, which are revalidated with conditional requests. Default: a directory in the user's cache directory")
+	rootCmd.PersistentFlags().Bool(config.NoCacheFlagName, false, "Don't use the on-disk cache of GitHub API responses")
+	rootCmd.PersistentFlags().String(config.RecordFlagName, "", "Record all HTTP interactions to files in this directory, with credentials scrubbed")
+	rootCmd.PersistentFlags().String(config.ReplayFlagName, "", "Answer all HTTP requests with the interactions recorded in this directory via --record, without network access")
+	rootCmd.PersistentFlags().String(config.GitHubHostFlagName, "", "Host name of the GitHub instance, e.g. of a GitHub Enterprise Server. Default: github.com")
+	rootCmd.Flags().StringP("chart-repo-prefix", "", config.DefaultChartRepoPrefix, "Prefix for chart repositories in the OCI registries")
+	rootCmd.Flags().StringP("public-oci-registry", "", config.DefaultPublicOCIRegistry, "Host name of the public OCI registry")
+	rootCmd.Flags().StringP("private-oci-registry", "", config.DefaultPrivateOCIRegistry, "Host name of the private OCI registry")
+	rootCmd.Flags().StringSliceP("chart-repositories", "", []string{config.DefaultChartRepository}, "URL prefixes of Helm chart repositories (besides the OCI registries) publishing our charts, used to resolve chart dependencies")
+	rootCmd.Flags().StringP("rules", "", "", "Path to a YAML file with rules adding links and annotations to entities. If empty, built-in default rules are used")
+	rootCmd.Flags().IntP("concurrency", "", 4, "Number of repositories to process in parallel")
+	rootCmd.Flags().StringP("repositories-dir", "", "", "Local directory with repository list YAML files, e.g. a checkout of giantswarm/github/repositories. Enables offline mode if no GitHub credentials are set.")
+	rootCmd.Flags().StringP("lists-schema-file", "", "", "Local JSON schema file to validate repository lists against. If empty, a built-in schema is used")
+	rootCmd.Flags().StringP("lists-schema-path", "", "", "Path of a JSON schema file in the lists repository to validate repository lists against, e.g. repositories/repositories.schema.json. Ignored in offline mode")
+	rootCmd.Flags().BoolP("chart-subcomponents", "", false, "For repositories with more than one Helm chart, create a subcomponent per chart")
+	rootCmd.Flags().BoolP("strict", "", false, "Fail if repository lists contain repositories that are missing, archived or renamed on GitHub, instead of skipping them")
+	rootCmd.Flags().StringP("systems-config", "", "", "YAML file defining domains and assigning systems to domains")
+	rootCmd.Flags().StringP("repositories-details", "", "", "Local JSON snapshot file with repository details (description, privacy, default branch)")
+
+	rootCmd.AddCommand(allCmd)
 	rootCmd.AddCommand(charts.Command)
 	rootCmd.AddCommand(crd.Command)
 	rootCmd.AddCommand(groups.Command)
 	rootCmd.AddCommand(installations.Command)
 	rootCmd.AddCommand(users.Command)
+	rootCmd.AddCommand(validateCmd)
 }
 
 func Execute() {
@@ -62,223 +87,560 @@ func Execute() {
 	}
 }
 
-func runRoot(cmd *cobra.Command, args []string) {
-	path, err := cmd.PersistentFlags().GetString("output")
+func runRoot(cmd *cobra.Command, args []string) error {
+	cfg, err := config.FromFlags(cmd.Root().PersistentFlags())
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("error loading config: %w", err)
 	}
 
-	repoPrefix, err := cmd.Flags().GetString("chart-repo-prefix")
+	path, err := config.String(cmd.Root().PersistentFlags(), "output", cfg.Output)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	// Remove leading and trailing slash if present
-	repoPrefix = strings.TrimPrefix(repoPrefix, "/")
-	repoPrefix = strings.TrimSuffix(repoPrefix, "/")
 
-	publicOciRegistry, err := cmd.Flags().GetString("public-oci-registry")
+	concurrency, err := cmd.Flags().GetInt("concurrency")
+	if err != nil {
+		return err
+	}
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1, got %d", concurrency)
+	}
+
+	creds, err := httpclient.CredentialsFromEnv()
+	if err != nil {
+		return err
+	}
+
+	host, err := config.GitHubHost(cmd.Root().PersistentFlags(), cfg)
+	if err != nil {
+		return err
+	}
+
+	clientOptions, err := config.GitHubClientOptions(cmd.Root().PersistentFlags(), cfg)
+	if err != nil {
+		return err
+	}
+	quota := httpclient.NewQuota()
+	client, err := httpclient.NewGitHubClient(creds.Token, append(clientOptions, httpclient.WithAppCredentials(creds.App), httpclient.WithQuota(quota))...)
+	if err != nil {
+		return fmt.Errorf("could not create GitHub client: %w", err)
+	}
+	defer log.Printf("GitHub API quota used: %s", quota)
+
+	numOrgs := 0
+	var stale []staleEntry
+	for _, org := range cfg.Organizations {
+		if org.Components == nil {
+			continue
+		}
+		numOrgs++
+
+		settings, err := componentSettingsFromFlags(cmd, org.Name, *org.Components)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Exporting components of organization %q", org.Name)
+		summaries, orgStale, err := exportComponents(client, host, settings, org.Name, path, creds, concurrency)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println()
+		for _, summary := range summaries {
+			fmt.Println(summary)
+		}
+		stale = append(stale, orgStale...)
+	}
+
+	if numOrgs == 0 {
+		return errors.New("no organization with components configured")
+	}
+
+	if len(stale) > 0 {
+		fmt.Printf("\nSkipped %s\n", staleReport(stale))
+	}
+
+	return nil
+}
+
+// componentSettingsFromFlags returns the component export settings for an
+// organization, with explicitly set flags taking precedence over the
+// configuration.
+func componentSettingsFromFlags(cmd *cobra.Command, organization string, c config.Components) (config.Components, error) {
+	flags := cmd.Flags()
+
+	var err error
+	for _, f := range []struct {
+		name  string
+		value *string
+	}{
+		{"chart-repo-prefix", &c.ChartRepoPrefix},
+		{"public-oci-registry", &c.PublicOCIRegistry},
+		{"private-oci-registry", &c.PrivateOCIRegistry},
+		{"rules", &c.Rules},
+		{"repositories-dir", &c.RepositoriesDir},
+		{"lists-schema-file", &c.ListsSchemaFile},
+		{"lists-schema-path", &c.ListsSchemaPath},
+		{"systems-config", &c.SystemsConfig},
+		{"repositories-details", &c.RepositoriesDetails},
+	} {
+		*f.value, err = config.String(flags, f.name, *f.value)
+		if err != nil {
+			return c, fmt.Errorf("organization %q: %w", organization, err)
+		}
+	}
+
+	c.ChartRepositories, err = config.StringSlice(flags, "chart-repositories", c.ChartRepositories)
 	if err != nil {
-		log.Fatal(err)
+		return c, fmt.Errorf("organization %q: %w", organization, err)
 	}
 
-	privateOciRegistry, err := cmd.Flags().GetString("private-oci-registry")
+	c.Strict, err = config.Bool(flags, "strict", c.Strict)
 	if err != nil {
-		log.Fatal(err)
+		return c, fmt.Errorf("organization %q: %w", organization, err)
 	}
 
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		log.Fatal("Please set environment variable GITHUB_TOKEN to a personal GitHub access token (PAT).")
+	c.ChartSubcomponents, err = config.Bool(flags, "chart-subcomponents", c.ChartSubcomponents)
+	if err != nil {
+		return c, fmt.Errorf("organization %q: %w", organization, err)
+	}
+
+	return c, nil
+}
+
+// normalizeComponentSettings cleans up component settings given via flags
+// or the configuration file, so that the components and all commands
+// export the same.
+func normalizeComponentSettings(c config.Components) config.Components {
+	// Remove leading and trailing slash if present
+	c.ChartRepoPrefix = strings.TrimPrefix(c.ChartRepoPrefix, "/")
+	c.ChartRepoPrefix = strings.TrimSuffix(c.ChartRepoPrefix, "/")
+
+	return c
+}
+
+// exportComponents exports the components, systems, and domains of one
+// organization.
+// Entries of stale repositories are skipped and returned, unless
+// settings.Strict is set.
+func exportComponents(client *github.Client, host githubhost.Host, settings config.Components, organization, path string, creds httpclient.Credentials, concurrency int) ([]export.Summary, []staleEntry, error) {
+	settings = normalizeComponentSettings(settings)
+
+	var systemsConfig *systemsconfig.File
+	if settings.SystemsConfig != "" {
+		configService, err := systemsconfig.New(systemsconfig.Config{FilePath: settings.SystemsConfig})
+		if err != nil {
+			return nil, nil, err
+		}
+		systemsConfig, err = configService.Load()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading systems config: %w", err)
+		}
+	}
+
+	rules, err := entityrules.LoadFile(settings.Rules)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading rules: %w", err)
+	}
+
+	offline := false
+	if creds.IsZero() {
+		if settings.RepositoriesDir == "" {
+			return nil, nil, errors.New("please set environment variable GITHUB_TOKEN to a personal GitHub access token (PAT), or configure a GitHub App")
+		}
+		log.Println("No GitHub credentials set, running in offline mode based on local files only.")
+		offline = true
 	}
 
 	repoService, err := repositories.New(repositories.Config{
-		GithubOrganization:   githubOrganization,
-		GithubRepositoryName: githubManagementRepository,
-		GithubAuthToken:      token,
-		DirectoryPath:        repositoriesPath,
+		GithubOrganization:   organization,
+		GithubRepositoryName: settings.ListsRepository,
+		GithubAuthToken:      creds.Token,
+		GithubClient:         client,
+		GithubHost:           host,
+		DirectoryPath:        settings.ListsPath,
+		LocalDirectoryPath:   settings.RepositoriesDir,
+		DetailsSnapshotPath:  settings.RepositoriesDetails,
+		SchemaPath:           settings.ListsSchemaFile,
+		SchemaRepositoryPath: settings.ListsSchemaPath,
+		Offline:              offline,
 	})
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, err
 	}
 
 	lists, err := repoService.GetLists()
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, err
 	}
 
-	componentExporter := export.New(export.Config{TargetPath: path + "/components.yaml"})
+	lists, stale, err := removeStaleRepos(repoService, organization, lists)
+	if err != nil {
+		return nil, nil, err
+	}
+	if settings.Strict && len(stale) > 0 {
+		return nil, nil, errors.New(staleReport(stale))
+	}
 
-	numComponents := 0
+	opts := componentOptions{
+		organization:       organization,
+		host:               host,
+		namespace:          settings.Namespace,
+		repoPrefix:         settings.ChartRepoPrefix,
+		publicOciRegistry:  settings.PublicOCIRegistry,
+		privateOciRegistry: settings.PrivateOCIRegistry,
+		chartRepositories:  settings.ChartRepositories,
+		rules:              rules,
+		chartSubcomponents: settings.ChartSubcomponents,
+	}
 
-	// Iterate repository lists (per team) and create component entities.
-	for _, list := range lists {
-		log.Printf("Processing %d repos of team %q\n", len(list.Repositories), list.OwnerTeamName)
+	componentEntities, err := createComponentEntities(repoService, lists, opts, concurrency)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		for _, repo := range list.Repositories {
+	systemEntities, domainEntities, err := createSystemEntities(lists, systemsConfig, settings.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var summaries []export.Summary
+	for _, file := range []struct {
+		name      string
+		path      string
+		entities  []*bscatalog.Entity
+		skipEmpty bool
+	}{
+		{"components", settings.OutputFile, componentEntities, false},
+		{"systems", settings.SystemsOutputFile, systemEntities, false},
+		// Domains only exist if configured.
+		{"domains", settings.DomainsOutputFile, domainEntities, true},
+	} {
+		if file.skipEmpty && len(file.entities) == 0 {
+			continue
+		}
 
-			ociRegistry := publicOciRegistry
-			isPrivate, err := repoService.GetIsPrivate(repo.Name)
+		exporter := export.New(export.Config{TargetPath: filepath.Join(path, file.path)})
+		for _, entity := range file.entities {
+			err = exporter.AddEntity(entity)
 			if err != nil {
-				log.Fatalf("Error: %v", err)
-			}
-			if isPrivate {
-				forcePublic, err := repoService.GetForcePublicRegistry(repo.Name)
-				if err != nil {
-					log.Fatalf("Error: %v", err)
-				}
-				if !forcePublic {
-					ociRegistry = privateOciRegistry
-				}
+				return summaries, stale, err
 			}
+		}
+		err = exporter.WriteFile()
+		if err != nil {
+			return summaries, stale, fmt.Errorf("error writing %s: %w", file.name, err)
+		}
+		summaries = append(summaries, exporter.Summary(file.name))
+	}
 
-			hasReadme, err := repoService.GetHasReadme(repo.Name)
+	return summaries, stale, nil
+}
+
+// createComponentEntities creates the component entities for all repositories
+// in the given lists, processing up to concurrency repositories in parallel.
+// Entities are returned in list order.
+func createComponentEntities(repoService *repositories.Service, lists []repositories.ListResult, opts componentOptions, concurrency int) ([]*bscatalog.Entity, error) {
+	// Collect all repos of all lists (per team) in a stable order, so that
+	// results can be gathered by index regardless of completion order.
+	type job struct {
+		ownerTeamName string
+		repo          repositories.Repo
+	}
+	var jobs []job
+	for _, list := range lists {
+		log.Printf("Processing %d repos of team %q\n", len(list.Repositories), list.OwnerTeamName)
+		for _, repo := range list.Repositories {
+			jobs = append(jobs, job{ownerTeamName: list.OwnerTeamName, repo: repo})
+		}
+	}
+
+	// Probe repository content in batches via GraphQL. Repos not covered
+	// here are probed one by one via the REST API later.
+	names := make([]string, len(jobs))
+	for i, j := range jobs {
+		names[i] = j.repo.Name
+	}
+	err := repoService.PrefetchContentDetails(names)
+	if err != nil {
+		log.Printf("WARN - could not prefetch content of some repositories via GraphQL, falling back to REST API for them: %v", err)
+	}
+
+	// Go module requirements are matched against all repos in the lists.
+	opts.repoNames = make(map[string]bool, len(names))
+	for _, name := range names {
+		opts.repoNames[name] = true
+	}
+
+	// Create components using a bounded pool of workers.
+	components := make([]*component.Component, len(jobs))
+	g := new(errgroup.Group)
+	g.SetLimit(concurrency)
+	for i, j := range jobs {
+		g.Go(func() error {
+			c, err := createComponent(repoService, j.ownerTeamName, j.repo, opts)
 			if err != nil {
-				log.Fatalf("Error: %v", err)
+				return fmt.Errorf("%s: %w", j.repo.Name, err)
 			}
+			components[i] = c
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
 
-			// Fetch Helm chart info if available.
-			var charts []*helmchart.Chart
-			var hasDeployableChart bool
-			{
-				numCharts, err := repoService.GetNumHelmCharts(repo.Name)
-				if err != nil {
-					log.Fatalf("Error: %v", err)
-				} else if numCharts > 0 {
-					chartNames, _ := repoService.GetHelmChartNames(repo.Name)
-					for _, chartName := range chartNames {
-						log.Printf("DEBUG - %s - fetching info on helm chart %s\n", repo.Name, chartName)
-						path := fmt.Sprintf("helm/%s/Chart.yaml", chartName)
-						data, err := repoService.LoadGitHubFile(repo.Name, path)
-						if err != nil {
-							if !repositories.IsFileNotFoundError(err) {
-								log.Printf("WARN - %s - error fetching helm chart %s: %v", repo.Name, chartName, err)
-							}
-						} else {
-							chart, err := helmchart.LoadString(data)
-							if err != nil {
-								log.Printf("WARN - %s - error parsing helm chart %s: %v", repo.Name, chartName, err)
-							} else {
-								charts = append(charts, chart)
-								if componentutil.IsChartDeployable(chart.Type) {
-									hasDeployableChart = true
-								}
-							}
-						}
-					}
-				}
-			}
+	if mismatches := codeOwnerMismatches(components); len(mismatches) > 0 {
+		log.Printf("WARN - %d repositories with owner team not in CODEOWNERS: %s", len(mismatches), strings.Join(mismatches, ", "))
+	}
 
-			description := repoService.MustGetDescription(repo.Name)
-			defaultBranch := repoService.MustGetDefaultBranch(repo.Name)
+	// Resolving dependencies requires knowing the charts of all components.
+	resolver := newChartDependencyResolver(components, opts.chartDependencyRepositories())
 
-			genLanguage := ""
-			if repo.Gen.Language != "" && repo.Gen.Language != repositories.RepoLanguageGeneric {
-				genLanguage = string(repo.Gen.Language)
-			}
+	// Names of all entities, to detect subcomponents colliding with them.
+	taken := newEntityNames(components)
 
-			genFlavors := make([]string, len(repo.Gen.Flavors))
-			for i, flavor := range repo.Gen.Flavors {
-				genFlavors[i] = string(flavor)
-			}
+	var entities []*bscatalog.Entity
+	for _, c := range components {
+		c.DependsOn = append(c.DependsOn, resolver.resolve(c)...)
+		slices.Sort(c.DependsOn)
+		c.DependsOn = slices.Compact(c.DependsOn)
 
-			c, err := component.New(
-				repo.Name,
-				component.WithCircleCiSlug(fmt.Sprintf("github/%s/%s", githubOrganization, repo.Name)),
-				component.WithDefaultBranch(defaultBranch),
-				component.WithDescription(description),
-				component.WithFlavors(genFlavors...),
-				component.WithGithubProjectSlug(fmt.Sprintf("%s/%s", githubOrganization, repo.Name)),
-				component.WithGithubTeamSlug(list.OwnerTeamName),
-				component.WithHasReadme(hasReadme),
-				component.WithHelmCharts(charts...),
-				component.WithLanguage(genLanguage),
-				component.WithLifecycle(string(repo.Lifecycle)),
-				component.WithOwner(list.OwnerTeamName),
-				component.WithPrivate(isPrivate),
-				component.WithSystem(repo.System),
-				component.WithType(repo.ComponentType),
-				component.WithOciRegistry(ociRegistry),
-				component.WithOciRepositoryPrefix(repoPrefix),
-			)
+		all := []*component.Component{c}
+		if opts.chartSubcomponents {
+			subcomponents, err := chartSubcomponents(c)
 			if err != nil {
-				log.Fatalf("Could not create component: %s", err)
+				return nil, fmt.Errorf("%s: %w", c.Name, err)
 			}
+			all = append(all, taken.addSubcomponents(c, subcomponents)...)
+		}
 
-			if hasDeployableChart {
-				c.AddTag("helmchart-deployable")
+		for _, c := range all {
+			entity := c.ToEntity()
+			if err := opts.rules.Apply(entity); err != nil {
+				return nil, fmt.Errorf("%s: %w", c.Name, err)
 			}
+			entities = append(entities, entity)
+		}
+	}
 
-			// Surface github repo-config settings as filterable catalog tags so
-			// the devportal catalog can be sliced company-wide by CI/release
-			// shape, not just team/type/flavour/language.
-			// Value tag (always exactly one) so the catalog tag picker, which
-			// only ANDs positive tags, can select both "generated" and the
-			// complement "manual" -- e.g. release:auto-release + ci:manual.
-			if repo.Gen.CI.Generate {
-				c.AddTag("ci:generated")
-			} else {
-				c.AddTag("ci:manual")
-			}
-			c.AddTag("release:" + repo.EffectiveReleaseWorkflow())
-			if repo.HasUpstreamCheck() {
-				c.AddTag("upstream-check")
-			}
-			if len(repo.Gen.PreCommit) > 0 {
-				c.AddTag("precommit")
-			}
+	return entities, nil
+}
+
+// componentOptions holds settings that apply to all components created by
+// the root command.
+type componentOptions struct {
+	// GitHub organization owning the repositories.
+	organization string
+
+	// GitHub instance hosting the repositories.
+	host githubhost.Host
+
+	// Namespace of the entities.
+	namespace string
+
+	// Prefix for chart repositories in the OCI registries.
+	repoPrefix string
+
+	// Host names of the OCI registries.
+	publicOciRegistry  string
+	privateOciRegistry string
+
+	// Additional Helm chart repository URLs (e.g. app catalogs) publishing
+	// our charts, used to resolve chart dependencies.
+	chartRepositories []string
+
+	// Names of all repositories in the lists.
+	repoNames map[string]bool
+
+	// Rules adding links and annotations to the entities.
+	rules *entityrules.Rules
+
+	// Whether to create a subcomponent per Helm chart for repositories
+	// with more than one chart.
+	chartSubcomponents bool
+}
+
+// chartDependencyRepositories returns the URL prefixes of all chart
+// repositories that publish charts of our components.
+func (o componentOptions) chartDependencyRepositories() []string {
+	repos := []string{
+		fmt.Sprintf("oci://%s/%s", o.publicOciRegistry, o.repoPrefix),
+		fmt.Sprintf("oci://%s/%s", o.privateOciRegistry, o.repoPrefix),
+	}
+	return append(repos, o.chartRepositories...)
+}
+
+// createComponent creates the component for one repository from a team's
+// repository list. It is safe for concurrent use.
+func createComponent(repoService *repositories.Service, ownerTeamName string, repo repositories.Repo, opts componentOptions) (*component.Component, error) {
+	ociRegistry := opts.publicOciRegistry
+	isPrivate, err := repoService.GetIsPrivate(repo.Name)
+	if err != nil {
+		return nil, err
+	}
+	if isPrivate {
+		forcePublic, err := repoService.GetForcePublicRegistry(repo.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !forcePublic {
+			ociRegistry = opts.privateOciRegistry
+		}
+	}
+
+	hasReadme, err := repoService.GetHasReadme(repo.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	githubWorkflows, err := repoService.GetGithubWorkflowNames(repo.Name)
+	if err != nil {
+		return nil, err
+	}
+	reusableWorkflows, err := repoService.GetReusableWorkflows(repo.Name)
+	if err != nil {
+		return nil, err
+	}
 
-			if len(charts) > 0 {
-				// Determine the chart's audience annotation, and if 'all', add tag.
-				// (This ignores the rare case where a repo may have more than one chart with different audience annotations.)
-				audience := ""
-				for _, chart := range charts {
-					if chart.Annotations != nil {
-						if val, exists := chart.Annotations["io.giantswarm.application.audience"]; exists {
-							audience = val
+	// Fetch Helm chart info if available.
+	var charts []*helmchart.Chart
+	var hasDeployableChart bool
+	{
+		numCharts, err := repoService.GetNumHelmCharts(repo.Name)
+		if err != nil {
+			return nil, err
+		} else if numCharts > 0 {
+			chartNames, _ := repoService.GetHelmChartNames(repo.Name)
+			for _, chartName := range chartNames {
+				log.Printf("DEBUG - %s - fetching info on helm chart %s\n", repo.Name, chartName)
+				data, err := repoService.LoadHelmChartFile(repo.Name, chartName)
+				if err != nil {
+					if !repositories.IsFileNotFoundError(err) {
+						log.Printf("WARN - %s - error fetching helm chart %s: %v", repo.Name, chartName, err)
+					}
+				} else {
+					chart, err := helmchart.LoadString(data)
+					if err != nil {
+						log.Printf("WARN - %s - error parsing helm chart %s: %v", repo.Name, chartName, err)
+					} else {
+						charts = append(charts, chart)
+						if componentutil.IsChartDeployable(chart.Type) {
+							hasDeployableChart = true
 						}
 					}
 				}
-				if audience == "all" {
-					c.AddTag("helmchart-audience-all")
-				}
-
-				// Set icon URL annotation if available
-				iconURL := selectIconURL(repo.Name, charts)
-				if iconURL != "" {
-					c.SetAnnotation(iconBackstageAnnotation, iconURL)
-				}
 			}
+		}
+	}
 
-			// Grafana dashboard link for services.
-			if repo.ComponentType == "service" {
-				baseName := strings.TrimSuffix(repo.Name, "-app")
-				nameWithApp := baseName + "-app"
-				c.AddLink(bscatalog.EntityLink{
-					URL:   fmt.Sprintf("https://giantswarm.grafana.net/d/eb617ba1-209a-4d57-9963-1af9a8ddc8d4/general-service-metrics?orgId=1&var-app=%s&var-app=%s&from=now-24h&to=now", baseName, nameWithApp),
-					Title: "General service metrics dashboard",
-					Icon:  "dashboard",
-					Type:  "grafana-dashboard",
-				})
+	// Dependencies on other Go modules of ours.
+	var goDependencies []string
+	if repo.Gen.Language == repositories.RepoLanguageGo {
+		data, err := repoService.LoadGitHubFile(repo.Name, "go.mod")
+		if err != nil {
+			if !repositories.IsFileNotFoundError(err) {
+				log.Printf("WARN - %s - error fetching go.mod: %v", repo.Name, err)
 			}
-
-			entity := c.ToEntity()
-			numComponents++
-
-			err = componentExporter.AddEntity(entity)
+		} else {
+			goDependencies, err = goModuleDependencies(opts.host, opts.organization, repo.Name, data, opts.repoNames)
 			if err != nil {
-				log.Fatalf("Error: %v", err)
+				log.Printf("WARN - %s - error parsing go.mod: %v", repo.Name, err)
 			}
-
 		}
 	}
 
-	err = componentExporter.WriteFile()
+	description := repoService.MustGetDescription(repo.Name)
+	defaultBranch := repoService.MustGetDefaultBranch(repo.Name)
+
+	genLanguage := ""
+	if repo.Gen.Language != "" && repo.Gen.Language != repositories.RepoLanguageGeneric {
+		genLanguage = string(repo.Gen.Language)
+	}
+
+	genFlavors := make([]string, len(repo.Gen.Flavors))
+	for i, flavor := range repo.Gen.Flavors {
+		genFlavors[i] = string(flavor)
+	}
+
+	c, err := component.New(
+		repo.Name,
+		component.WithCircleCiSlug(fmt.Sprintf("github/%s/%s", opts.organization, repo.Name)),
+		component.WithDefaultBranch(defaultBranch),
+		component.WithDependsOn(goDependencies...),
+		component.WithDescription(description),
+		component.WithFlavors(genFlavors...),
+		component.WithGithubProjectSlug(fmt.Sprintf("%s/%s", opts.organization, repo.Name)),
+		component.WithGithubHost(opts.host),
+		component.WithNamespace(opts.namespace),
+		component.WithGithubTeamSlug(ownerTeamName),
+		component.WithGithubWorkflows(githubWorkflows...),
+		component.WithReusableWorkflows(reusableWorkflows...),
+		component.WithHasReadme(hasReadme),
+		component.WithHelmCharts(charts...),
+		component.WithLanguage(genLanguage),
+		component.WithLifecycle(string(repo.Lifecycle)),
+		component.WithOwner(ownerTeamName),
+		component.WithPrivate(isPrivate),
+		component.WithSystem(repo.System),
+		component.WithType(repo.ComponentType),
+		component.WithOciRegistry(ociRegistry),
+		component.WithOciRepositoryPrefix(opts.repoPrefix),
+	)
 	if err != nil {
-		log.Fatalf("Error writing components: %v", err)
+		return nil, fmt.Errorf("could not create component: %w", err)
+	}
+
+	if hasDeployableChart {
+		c.AddTag("helmchart-deployable")
+	}
+
+	// Surface github repo-config settings as filterable catalog tags so
+	// the devportal catalog can be sliced company-wide by CI/release
+	// shape, not just team/type/flavour/language.
+	// Value tag (always exactly one) so the catalog tag picker, which
+	// only ANDs positive tags, can select both "generated" and the
+	// complement "manual" -- e.g. release:auto-release + ci:manual.
+	if repo.Gen.CI.Generate {
+		c.AddTag("ci:generated")
+	} else {
+		c.AddTag("ci:manual")
 	}
+	c.AddTag("release:" + repo.EffectiveReleaseWorkflow())
+	if repo.HasUpstreamCheck() {
+		c.AddTag("upstream-check")
+	}
+	if len(repo.Gen.PreCommit) > 0 {
+		c.AddTag("precommit")
+	}
+
+	if len(charts) > 0 {
+		// Determine the chart's audience annotation, and if 'all', add tag.
+		// (This ignores the rare case where a repo may have more than one chart
+		// with different audience annotations. Chart subcomponents carry the
+		// audience of their own chart.)
+		audience := ""
+		for _, chart := range charts {
+			if val := chartAudience(chart); val != "" {
+				audience = val
+			}
+		}
+		if audience == "all" {
+			c.AddTag("helmchart-audience-all")
+		}
+
+		// Set icon URL annotation if available
+		iconURL := selectIconURL(repo.Name, charts)
+		if iconURL != "" {
+			c.SetAnnotation(iconBackstageAnnotation, iconURL)
+		}
+	}
+
+	setCodeOwners(repoService, opts.organization, c)
+	mergeCatalogInfo(repoService, c)
 
-	fmt.Printf("\n%d components written to file %s with size %d bytes", numComponents, componentExporter.TargetPath, componentExporter.Len())
-	fmt.Println("")
+	return c, nil
 }
 
 // selectIconURL extracts and selects an icon URL from the given charts.
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/config"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/entityrules"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/repositories"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/export"
+)
+
+var (
+	update = flag.Bool("update", false, "update the golden files of this test")
+)
+
+// TestCreateComponentEntitiesOffline runs the component export based on local
+// repository lists and a details snapshot, without any network access.
+func TestCreateComponentEntitiesOffline(t *testing.T) {
+	repoService, err := repositories.New(repositories.Config{
+		GithubOrganization:  config.DefaultOrganization,
+		LocalDirectoryPath:  "testdata/repositories",
+		DetailsSnapshotPath: "testdata/repositories-details.json",
+		Offline:             true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	lists, err := repoService.GetLists()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	rules, err := entityrules.Default()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	opts := componentOptions{
+		organization:       config.DefaultOrganization,
+		namespace:          config.DefaultNamespace,
+		repoPrefix:         "charts/giantswarm",
+		publicOciRegistry:  "gsoci.azurecr.io",
+		privateOciRegistry: "gsociprivate.azurecr.io",
+		chartRepositories:  []string{"https://giantswarm.github.io/"},
+		rules:              rules,
+	}
+
+	entities, err := createComponentEntities(repoService, lists, opts, 2)
+	if err != nil {
+		t.Fatalf("createComponentEntities() unexpected error %v", err)
+	}
+
+	exporter := export.New(export.Config{})
+	for _, e := range entities {
+		_ = exporter.AddEntity(e)
+	}
+
+	got := exporter.String()
+	want := goldenValue(t, "testdata/components-offline.golden", got, *update)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("createComponentEntities() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestExportComponentsReplay runs the component export against recorded
+// GitHub REST and GraphQL interactions, without any network access. Replay
+// matches GraphQL requests by body, so queries must be built the same way
+// on every run.
+func TestExportComponentsReplay(t *testing.T) {
+	restore, err := httpclient.Replay("testdata/replay")
+	if err != nil {
+		t.Fatalf("Replay() unexpected error %v", err)
+	}
+	defer restore()
+
+	// Clients have to be created after starting the replay.
+	client, err := httpclient.NewGitHubClient("token")
+	if err != nil {
+		t.Fatalf("NewGitHubClient() unexpected error %v", err)
+	}
+
+	// Settings are normalized the same way for all commands.
+	settings := *config.Default().Organization(config.DefaultOrganization).Components
+	settings.ChartRepoPrefix = "/" + settings.ChartRepoPrefix + "/"
+	path := t.TempDir()
+	_, stale, err := exportComponents(client, "", settings, config.DefaultOrganization, path, httpclient.Credentials{Token: "token"}, 2)
+	if err != nil {
+		t.Fatalf("exportComponents() unexpected error %v", err)
+	}
+
+	wantStale := `1 stale repository list entries:
+  giantswarm/team-atlas: dashboards: missing`
+	if diff := cmp.Diff(wantStale, staleReport(stale)); diff != "" {
+		t.Errorf("exportComponents() stale mismatch (-want +got):\n%s", diff)
+	}
+
+	data, err := os.ReadFile(filepath.Join(path, settings.OutputFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	want := goldenValue(t, "testdata/components-replay.golden", got, *update)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("exportComponents() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func goldenValue(t *testing.T, goldenPath string, actual string, update bool) string {
+	t.Helper()
+
+	if update {
+		err := os.WriteFile(goldenPath, []byte(actual), 0600)
+		if err != nil {
+			t.Fatalf("Error writing to file %s: %s", goldenPath, err)
+		}
+		return actual
+	}
+
+	content, err := os.ReadFile(goldenPath) //nolint:gosec
+	if err != nil {
+		t.Fatalf("Error opening file %s: %s", goldenPath, err)
+	}
+	return string(content)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/repositories"
+)
+
+// staleEntry is a repository list entry whose repository is missing,
+// archived, or renamed on GitHub.
+type staleEntry struct {
+	organization string
+
+	// Name of the list (owner team) containing the entry.
+	list string
+
+	repositories.StaleRepo
+}
+
+func (e staleEntry) String() string {
+	return fmt.Sprintf("%s/%s: %s", e.organization, e.list, e.StaleRepo)
+}
+
+// removeStaleRepos returns the lists without the entries of stale
+// repositories, and these entries.
+func removeStaleRepos(repoService *repositories.Service, organization string, lists []repositories.ListResult) ([]repositories.ListResult, []staleEntry, error) {
+	var stale []staleEntry
+	result := make([]repositories.ListResult, len(lists))
+	for i, list := range lists {
+		result[i] = repositories.ListResult{OwnerTeamName: list.OwnerTeamName}
+		for _, repo := range list.Repositories {
+			s, err := repoService.CheckRepo(repo.Name)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", repo.Name, err)
+			}
+			if s != nil {
+				log.Printf("WARN - %s - skipping stale repository list entry: %s", repo.Name, s)
+				stale = append(stale, staleEntry{organization: organization, list: list.OwnerTeamName, StaleRepo: *s})
+				continue
+			}
+			result[i].Repositories = append(result[i].Repositories, repo)
+		}
+	}
+
+	return result, stale, nil
+}
+
+// staleReport describes the given stale entries, one per line.
+func staleReport(entries []staleEntry) string {
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = "  " + e.String()
+	}
+	return fmt.Sprintf("%d stale repository list entries:\n%s", len(entries), strings.Join(lines, "\n"))
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v90/github"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/config"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/repositories"
+)
+
+func TestRemoveStaleRepos(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/giantswarm/repos", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("/repos/giantswarm/helm-chart-library", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name": "helm-charts", "full_name": "giantswarm/helm-charts", "owner": {"login": "giantswarm"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	baseURL := server.URL + "/"
+	client, err := github.NewClient(github.WithURLs(&baseURL, &baseURL))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	snapshot := filepath.Join(t.TempDir(), "details.json")
+	err = os.WriteFile(snapshot, []byte(`[{"name": "app-operator"}]`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repoService, err := repositories.New(repositories.Config{
+		GithubOrganization:  config.DefaultOrganization,
+		GithubClient:        client,
+		LocalDirectoryPath:  "testdata/repositories",
+		DetailsSnapshotPath: snapshot,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	lists, err := repoService.GetLists()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	lists, stale, err := removeStaleRepos(repoService, config.DefaultOrganization, lists)
+	if err != nil {
+		t.Fatalf("removeStaleRepos() unexpected error %v", err)
+	}
+
+	var remaining []string
+	for _, list := range lists {
+		for _, repo := range list.Repositories {
+			remaining = append(remaining, repo.Name)
+		}
+	}
+	if diff := cmp.Diff([]string{"app-operator"}, remaining); diff != "" {
+		t.Errorf("removeStaleRepos() lists mismatch (-want +got):\n%s", diff)
+	}
+
+	want := `2 stale repository list entries:
+  giantswarm/team-atlas: dashboards: missing
+  giantswarm/team-honeybadger: helm-chart-library: renamed to helm-charts`
+	if diff := cmp.Diff(want, staleReport(stale)); diff != "" {
+		t.Errorf("staleReport() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/helmchart"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/component"
+	componentutil "github.com/giantswarm/backstage-catalog-importer/pkg/util/component"
+)
+
+const (
+	// Helm chart annotation key for the audience of the chart.
+	audienceChartAnnotation = "io.giantswarm.application.audience"
+
+	// Suffix of the names of chart subcomponents.
+	chartSubcomponentSuffix = "-chart"
+
+	// Type of chart subcomponents. Rules for the repository's component
+	// type, e.g. service dashboards, don't apply to them.
+	chartSubcomponentType = "helmchart"
+)
+
+// chartSubcomponents returns one subcomponent per Helm chart of the given
+// component, if it has more than one chart. Subcomponents inherit owner,
+// system, lifecycle and repository details from the component, but carry
+// the version, audience, icon and deployability of their own chart. They
+// are of type helmchart, so that they don't get a kubernetes-id of their
+// own, which would show the component's workloads twice.
+func chartSubcomponents(c *component.Component) ([]*component.Component, error) {
+	if len(c.HelmCharts) < 2 {
+		return nil, nil
+	}
+
+	var subcomponents []*component.Component
+	for _, chart := range c.HelmCharts {
+		sub, err := component.New(
+			chart.Name+chartSubcomponentSuffix,
+			component.WithTitle(chart.Name),
+			component.WithDescription(chart.Description),
+			component.WithNamespace(c.Namespace),
+			component.WithSubcomponentOf(c.Name),
+			component.WithOwner(c.Owner),
+			component.WithGithubTeamSlug(c.GithubTeamSlug),
+			component.WithGithubProjectSlug(c.GithubProjectSlug),
+			component.WithGithubHost(c.GithubHost),
+			component.WithSystem(c.System),
+			component.WithType(chartSubcomponentType),
+			component.WithLifecycle(c.Lifecycle),
+			component.WithPrivate(c.IsPrivate),
+			component.WithHelmCharts(chart),
+			component.WithOciRegistry(c.OciRegistry),
+			component.WithOciRepositoryPrefix(c.OciRepositoryPrefix),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if componentutil.IsChartDeployable(chart.Type) {
+			sub.AddTag("helmchart-deployable")
+		}
+		if chartAudience(chart) == "all" {
+			sub.AddTag("helmchart-audience-all")
+		}
+		if chart.Icon != "" {
+			sub.SetAnnotation(iconBackstageAnnotation, chart.Icon)
+		}
+
+		subcomponents = append(subcomponents, sub)
+	}
+
+	return subcomponents, nil
+}
+
+// entityNames holds the names of the component entities exported so far.
+type entityNames map[string]bool
+
+func newEntityNames(components []*component.Component) entityNames {
+	names := make(entityNames, len(components))
+	for _, c := range components {
+		names[c.Name] = true
+	}
+	return names
+}
+
+// addSubcomponents returns the subcomponents of c whose names are not taken
+// yet, and adds their names. Subcomponents colliding with another entity
+// are skipped with a warning.
+func (n entityNames) addSubcomponents(c *component.Component, subcomponents []*component.Component) []*component.Component {
+	var result []*component.Component
+	for _, sub := range subcomponents {
+		if n[sub.Name] {
+			log.Printf("WARN - %s - skipping chart subcomponent %s, an entity of that name already exists", c.Name, sub.Name)
+			continue
+		}
+		n[sub.Name] = true
+		result = append(result, sub)
+	}
+	return result
+}
+
+// chartAudience returns the audience annotation value of the chart, if any.
+func chartAudience(chart *helmchart.Chart) string {
+	return chart.Annotations[audienceChartAnnotation]
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/helmchart"
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/component"
+)
+
+func TestChartSubcomponents(t *testing.T) {
+	appChart := &helmchart.Chart{Metadata: chart.Metadata{
+		Name:        "cluster-aws",
+		Description: "Cluster on AWS",
+		Version:     "1.2.3",
+		AppVersion:  "0.1.0",
+		Icon:        "https://example.com/aws.svg",
+		Annotations: map[string]string{audienceChartAnnotation: "all"},
+	}}
+	libraryChart := &helmchart.Chart{Metadata: chart.Metadata{
+		Name:    "cluster-shared",
+		Version: "0.5.0",
+		Type:    "library",
+	}}
+
+	single, err := component.New("single", component.WithHelmCharts(appChart))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	got, err := chartSubcomponents(single)
+	if err != nil || got != nil {
+		t.Errorf("chartSubcomponents() with one chart = %v, %v, want nil, nil", got, err)
+	}
+
+	c, err := component.New("cluster-aws",
+		component.WithHelmCharts(appChart, libraryChart),
+		component.WithOwner("team-phoenix"),
+		component.WithSystem("cluster-api"),
+		component.WithType("service"),
+		component.WithGithubProjectSlug("giantswarm/cluster-aws"),
+		component.WithOciRegistry("gsoci.azurecr.io"),
+		component.WithOciRepositoryPrefix("charts/giantswarm"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	subcomponents, err := chartSubcomponents(c)
+	if err != nil {
+		t.Fatalf("chartSubcomponents() unexpected error %v", err)
+	}
+	if len(subcomponents) != 2 {
+		t.Fatalf("chartSubcomponents() returned %d subcomponents, want 2", len(subcomponents))
+	}
+
+	app := subcomponents[0].ToEntity()
+	wantMetadata := bscatalog.EntityMetadata{
+		Name:        "cluster-aws-chart",
+		Title:       "cluster-aws",
+		Description: "Cluster on AWS",
+		Labels:      map[string]string{},
+		Links:       []bscatalog.EntityLink{},
+		Tags:        []string{"helmchart", "helmchart-audience-all", "helmchart-deployable"},
+		Annotations: map[string]string{
+			"backstage.io/source-location":         "url:https://github.com/giantswarm/cluster-aws",
+			"github.com/project-slug":              "giantswarm/cluster-aws",
+			"giantswarm.io/helmcharts":             "gsoci.azurecr.io/charts/giantswarm/cluster-aws",
+			"giantswarm.io/helmchart-versions":     "1.2.3",
+			"giantswarm.io/helmchart-app-versions": "0.1.0",
+			iconBackstageAnnotation:                "https://example.com/aws.svg",
+		},
+	}
+	if diff := cmp.Diff(wantMetadata, app.Metadata); diff != "" {
+		t.Errorf("chart subcomponent metadata mismatch (-want +got):\n%s", diff)
+	}
+	wantSpec := bscatalog.ComponentSpec{
+		Type:           chartSubcomponentType,
+		Lifecycle:      "production",
+		Owner:          "team-phoenix",
+		System:         "cluster-api",
+		SubcomponentOf: "cluster-aws",
+	}
+	if diff := cmp.Diff(wantSpec, app.Spec); diff != "" {
+		t.Errorf("chart subcomponent spec mismatch (-want +got):\n%s", diff)
+	}
+
+	library := subcomponents[1].ToEntity()
+	if diff := cmp.Diff([]string{"helmchart"}, library.Metadata.Tags); diff != "" {
+		t.Errorf("library chart subcomponent tags mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEntityNamesAddSubcomponents(t *testing.T) {
+	newComponent := func(name string) *component.Component {
+		c, err := component.New(name)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return c
+	}
+
+	parent := newComponent("cluster")
+	names := newEntityNames([]*component.Component{parent, newComponent("cluster-aws-chart")})
+
+	got := names.addSubcomponents(parent, []*component.Component{
+		newComponent("cluster-aws-chart"),
+		newComponent("cluster-azure-chart"),
+		newComponent("cluster-azure-chart"),
+	})
+
+	var gotNames []string
+	for _, c := range got {
+		gotNames = append(gotNames, c.Name)
+	}
+	if diff := cmp.Diff([]string{"cluster-azure-chart"}, gotNames); diff != "" {
+		t.Errorf("addSubcomponents() mismatch (-want +got):\n%s", diff)
+	}
+}
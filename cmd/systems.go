@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"cmp"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/repositories"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/systemsconfig"
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/domain"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/system"
+)
+
+// createSystemEntities creates a System entity for every distinct system
+// referenced by repositories in the lists, plus every system in the config.
+// It also returns the Domain entities defined in the config. config may be nil.
+// All entities are created in the given namespace.
+func createSystemEntities(lists []repositories.ListResult, config *systemsconfig.File, namespace string) (systems []*bscatalog.Entity, domains []*bscatalog.Entity, err error) {
+	if config == nil {
+		config = &systemsconfig.File{}
+	}
+
+	owners := inferSystemOwners(lists)
+
+	names := make(map[string]bool)
+	for name := range owners {
+		names[name] = true
+	}
+	for _, s := range config.Systems {
+		names[s.Name] = true
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		opts := []system.Option{
+			system.WithNamespace(namespace),
+			system.WithOwner(owners[name]),
+		}
+		if conf := config.SystemByName(name); conf != nil {
+			opts = append(opts,
+				system.WithOwner(conf.Owner),
+				system.WithDomain(conf.Domain),
+				system.WithTitle(conf.Title),
+				system.WithDescription(conf.Description),
+			)
+		}
+
+		s, err := system.New(name, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		systems = append(systems, s.ToEntity())
+	}
+
+	for _, conf := range config.Domains {
+		d, err := domain.New(conf.Name,
+			domain.WithNamespace(namespace),
+			domain.WithOwner(conf.Owner),
+			domain.WithTitle(conf.Title),
+			domain.WithDescription(conf.Description),
+			domain.WithSubdomainOf(conf.SubdomainOf),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		domains = append(domains, d.ToEntity())
+	}
+
+	return systems, domains, nil
+}
+
+// inferSystemOwners returns the owner team for every system referenced in
+// the lists. If repos of several teams use a system, the team with the most
+// repos in the system wins, with ties broken alphabetically.
+func inferSystemOwners(lists []repositories.ListResult) map[string]string {
+	// system name -> team name -> number of repos
+	counts := make(map[string]map[string]int)
+	for _, list := range lists {
+		for _, repo := range list.Repositories {
+			if repo.System == "" {
+				continue
+			}
+			if counts[repo.System] == nil {
+				counts[repo.System] = make(map[string]int)
+			}
+			counts[repo.System][list.OwnerTeamName]++
+		}
+	}
+
+	owners := make(map[string]string, len(counts))
+	for systemName, teams := range counts {
+		teamNames := slices.Collect(maps.Keys(teams))
+		slices.SortFunc(teamNames, func(a, b string) int {
+			return cmp.Or(
+				cmp.Compare(teams[b], teams[a]),
+				cmp.Compare(a, b),
+			)
+		})
+		owners[systemName] = teamNames[0]
+
+		if len(teamNames) > 1 {
+			log.Printf("INFO - system %q is used by repos of several teams (%s), using %q as owner", systemName, strings.Join(teamNames, ", "), teamNames[0])
+		}
+	}
+
+	return owners
+}
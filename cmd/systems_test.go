@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/repositories"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/systemsconfig"
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+)
+
+func TestCreateSystemEntities(t *testing.T) {
+	lists := []repositories.ListResult{
+		{
+			OwnerTeamName: "team-atlas",
+			Repositories: []repositories.Repo{
+				{Name: "dashboards", System: "observability"},
+				{Name: "app-exporter", System: "app-platform"},
+			},
+		},
+		{
+			OwnerTeamName: "team-honeybadger",
+			Repositories: []repositories.Repo{
+				{Name: "app-operator", System: "app-platform"},
+				{Name: "chart-operator", System: "app-platform"},
+				{Name: "no-system"},
+			},
+		},
+	}
+	config := &systemsconfig.File{
+		Domains: []systemsconfig.Domain{
+			{Name: "platform", Owner: "group:team-honeybadger"},
+		},
+		Systems: []systemsconfig.System{
+			{Name: "app-platform", Domain: "platform", Title: "App Platform"},
+			{Name: "unused", Owner: "group:team-bigmac"},
+		},
+	}
+
+	systems, domains, err := createSystemEntities(lists, config, "default")
+	if err != nil {
+		t.Fatalf("createSystemEntities() unexpected error: %v", err)
+	}
+
+	wantSystems := []*bscatalog.Entity{
+		{
+			APIVersion: bscatalog.APIVersion,
+			Kind:       bscatalog.EntityKindSystem,
+			Metadata:   bscatalog.EntityMetadata{Name: "app-platform", Title: "App Platform"},
+			Spec:       bscatalog.SystemSpec{Owner: "team-honeybadger", Domain: "platform"},
+		},
+		{
+			APIVersion: bscatalog.APIVersion,
+			Kind:       bscatalog.EntityKindSystem,
+			Metadata:   bscatalog.EntityMetadata{Name: "observability"},
+			Spec:       bscatalog.SystemSpec{Owner: "team-atlas"},
+		},
+		{
+			APIVersion: bscatalog.APIVersion,
+			Kind:       bscatalog.EntityKindSystem,
+			Metadata:   bscatalog.EntityMetadata{Name: "unused"},
+			Spec:       bscatalog.SystemSpec{Owner: "group:team-bigmac"},
+		},
+	}
+	if diff := cmp.Diff(wantSystems, systems); diff != "" {
+		t.Errorf("createSystemEntities() systems mismatch (-want +got):\n%s", diff)
+	}
+
+	wantDomains := []*bscatalog.Entity{
+		{
+			APIVersion: bscatalog.APIVersion,
+			Kind:       bscatalog.EntityKindDomain,
+			Metadata:   bscatalog.EntityMetadata{Name: "platform"},
+			Spec:       bscatalog.DomainSpec{Owner: "group:team-honeybadger"},
+		},
+	}
+	if diff := cmp.Diff(wantDomains, domains); diff != "" {
+		t.Errorf("createSystemEntities() domains mismatch (-want +got):\n%s", diff)
+	}
+}
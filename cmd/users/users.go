@@ -3,11 +3,16 @@ package users
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
+	"github.com/google/go-github/v90/github"
 	"github.com/spf13/cobra"
 
+	"github.com/giantswarm/backstage-catalog-importer/pkg/config"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/input/personio"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/output/catalog/user"
@@ -15,10 +20,12 @@ import (
 )
 
 var Command = &cobra.Command{
-	Use:   "users",
-	Short: "Export users catalog",
-	Long:  `Exports Giant Swarm users, either for customer catalogs or for GS-internal use.`,
-	RunE:  run,
+	Use:           "users",
+	Short:         "Export users catalog",
+	Long:          `Exports Giant Swarm users, either for customer catalogs or for GS-internal use.`,
+	RunE:          run,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
 const (
@@ -28,48 +35,77 @@ const (
 
 func init() {
 	Command.Flags().BoolP(internalFlag, "i", false, "Create a Giant Swarm internal catalog, which includes email adresses.")
-	Command.PersistentFlags().StringP(outputFlag, "o", ".", "Output directory path")
+	Command.PersistentFlags().StringP(outputFlag, "o", config.DefaultOutput, "Output directory path")
 }
 
 func run(cmd *cobra.Command, args []string) error {
-	// Personio credentials
-	personioClientID := os.Getenv("PERSONIO_CLIENT_ID")
-	if personioClientID == "" {
-		log.Fatal("Please set environment variable PERSONIO_CLIENT_ID to the Personio client ID.")
+	// GitHub credentials
+	creds, err := httpclient.CredentialsFromEnv()
+	if err != nil {
+		return err
 	}
-	personioClientSecret := os.Getenv("PERSONIO_CLIENT_SECRET")
-	if personioClientSecret == "" {
-		log.Fatal("Please set environment variable PERSONIO_CLIENT_SECRET to the Personio client secret.")
+	if creds.IsZero() {
+		return errors.New("please set environment variable GITHUB_TOKEN to a personal GitHub access token (PAT), or configure a GitHub App")
 	}
 
-	// GitHub credentials
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		log.Fatal("Please set environment variable GITHUB_TOKEN to a personal GitHub access token (PAT).")
+	cfg, err := config.FromFlags(cmd.Root().PersistentFlags())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
 	}
 
-	internal, err := cmd.Flags().GetBool(internalFlag)
+	settings := *cfg.Users
+	settings.Internal, err = config.Bool(cmd.Flags(), internalFlag, settings.Internal)
 	if err != nil {
 		return err
 	}
 
-	path, err := cmd.PersistentFlags().GetString(outputFlag)
+	path, err := config.String(cmd.PersistentFlags(), outputFlag, cfg.Output)
 	if err != nil {
-		log.Fatalf("Error: could not access 'output' flag - %s", err)
+		return fmt.Errorf("could not access 'output' flag: %w", err)
 	}
 
-	ctx := context.Background()
+	clientOptions, err := config.GitHubClientOptions(cmd.Root().PersistentFlags(), cfg)
+	if err != nil {
+		return err
+	}
+	quota := httpclient.NewQuota()
+	githubClient, err := httpclient.NewGitHubClient(creds.Token, append(clientOptions, httpclient.WithAppCredentials(creds.App), httpclient.WithQuota(quota))...)
+	if err != nil {
+		return fmt.Errorf("could not create GitHub client: %w", err)
+	}
+	defer log.Printf("GitHub API quota used: %s", quota)
 
-	githubClient, err := httpclient.NewGitHubClient(token)
+	summary, err := Export(githubClient, settings, path)
 	if err != nil {
-		log.Fatalf("Error: could not create GitHub client -- %v", err)
+		return err
+	}
+
+	fmt.Printf("\n%s\n", summary)
+
+	return nil
+}
+
+// Export exports the active employees as users to a file in the path
+// directory. Employees are read from Personio, using the credentials from
+// the PERSONIO_CLIENT_ID and PERSONIO_CLIENT_SECRET environment variables.
+func Export(githubClient *github.Client, settings config.Users, path string) (export.Summary, error) {
+	// Personio credentials
+	personioClientID := os.Getenv("PERSONIO_CLIENT_ID")
+	if personioClientID == "" {
+		return export.Summary{}, errors.New("please set environment variable PERSONIO_CLIENT_ID to the Personio client ID")
+	}
+	personioClientSecret := os.Getenv("PERSONIO_CLIENT_SECRET")
+	if personioClientSecret == "" {
+		return export.Summary{}, errors.New("please set environment variable PERSONIO_CLIENT_SECRET to the Personio client secret")
 	}
 
-	userExporter := export.New(export.Config{TargetPath: path + "/users.yaml"})
+	ctx := context.Background()
+
+	userExporter := export.New(export.Config{TargetPath: filepath.Join(path, settings.OutputFile)})
 
 	employees, err := personio.GetActiveEmployees(ctx, personioClientID, personioClientSecret)
 	if err != nil {
-		log.Fatalf("Error: could not get employees from Personio -- %v", err)
+		return export.Summary{}, fmt.Errorf("could not get employees from Personio -- %w", err)
 	}
 
 	for _, employee := range employees {
@@ -81,7 +117,7 @@ func run(cmd *cobra.Command, args []string) error {
 		}
 		githubDetails, _, err := githubClient.Users.Get(ctx, employee.GithubHandle)
 		if err != nil {
-			log.Fatalf("Error: could not read detailed user entry -- %v", err)
+			return export.Summary{}, fmt.Errorf("could not read detailed user entry -- %w", err)
 		}
 
 		user, err := user.New(employee.GithubHandle,
@@ -92,10 +128,10 @@ func run(cmd *cobra.Command, args []string) error {
 			user.WithGitHubID(githubDetails.GetID()),
 		)
 		if err != nil {
-			log.Fatalf("Error: could not create user - %v", err)
+			return export.Summary{}, fmt.Errorf("could not create user - %w", err)
 		}
 
-		if internal {
+		if settings.Internal {
 			user.DisplayName = employee.FirstName + " " + employee.LastName
 		} else {
 			user.DisplayName = githubDetails.GetName()
@@ -103,14 +139,14 @@ func run(cmd *cobra.Command, args []string) error {
 
 		err = userExporter.AddEntity(user.ToEntity())
 		if err != nil {
-			log.Fatalf("Error: could not add user entity - %v", err)
+			return export.Summary{}, fmt.Errorf("could not add user entity - %w", err)
 		}
 	}
 
 	err = userExporter.WriteFile()
 	if err != nil {
-		log.Fatalf("Error: could not write users -- %v", err)
+		return export.Summary{}, fmt.Errorf("could not write users -- %w", err)
 	}
 
-	return nil
+	return userExporter.Summary("users"), nil
 }
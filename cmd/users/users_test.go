@@ -0,0 +1,73 @@
+package users
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/config"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
+)
+
+var (
+	update = flag.Bool("update", false, "update the golden files of this test")
+)
+
+// TestExportReplay runs the users export against recorded Personio and
+// GitHub interactions, without any network access.
+func TestExportReplay(t *testing.T) {
+	t.Setenv("PERSONIO_CLIENT_ID", "client-id")
+	t.Setenv("PERSONIO_CLIENT_SECRET", "client-secret")
+
+	restore, err := httpclient.Replay("testdata/replay")
+	if err != nil {
+		t.Fatalf("Replay() unexpected error %v", err)
+	}
+	defer restore()
+
+	// Clients have to be created after starting the replay.
+	client, err := httpclient.NewGitHubClient("token")
+	if err != nil {
+		t.Fatalf("NewGitHubClient() unexpected error %v", err)
+	}
+
+	path := t.TempDir()
+	summary, err := Export(client, config.Users{OutputFile: config.DefaultUsersOutputFile}, path)
+	if err != nil {
+		t.Fatalf("Export() unexpected error %v", err)
+	}
+	if summary.Count != 1 {
+		t.Errorf("Export() exported %d users, want 1", summary.Count)
+	}
+
+	data, err := os.ReadFile(filepath.Join(path, config.DefaultUsersOutputFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	want := goldenValue(t, "testdata/users.golden", got, *update)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Export() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func goldenValue(t *testing.T, goldenPath string, actual string, update bool) string {
+	t.Helper()
+
+	if update {
+		err := os.WriteFile(goldenPath, []byte(actual), 0600)
+		if err != nil {
+			t.Fatalf("Error writing to file %s: %s", goldenPath, err)
+		}
+		return actual
+	}
+
+	content, err := os.ReadFile(goldenPath) //nolint:gosec
+	if err != nil {
+		t.Fatalf("Error opening file %s: %s", goldenPath, err)
+	}
+	return string(content)
+}
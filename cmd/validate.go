@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/go-github/v90/github"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/config"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/input/repositories"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate repository lists without exporting anything",
+	Long: `Validates the repository lists of every organization with a components
+section in the configuration file against the repositories JSON schema.
+
+Every problem found is printed with file, line, and repository name. The
+command fails if any problem was found.`,
+	Args:          cobra.NoArgs,
+	RunE:          runValidate,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	validateCmd.Flags().StringP("repositories-dir", "", "", "Local directory with repository list YAML files. Enables offline mode if no GitHub credentials are set.")
+	validateCmd.Flags().StringP("lists-schema-file", "", "", "Local JSON schema file to validate repository lists against. If empty, a built-in schema is used")
+	validateCmd.Flags().StringP("lists-schema-path", "", "", "Path of a JSON schema file in the lists repository to validate repository lists against. Ignored in offline mode")
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	cfg, err := config.FromFlags(cmd.Root().PersistentFlags())
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+
+	creds, err := httpclient.CredentialsFromEnv()
+	if err != nil {
+		return err
+	}
+
+	host, err := config.GitHubHost(cmd.Root().PersistentFlags(), cfg)
+	if err != nil {
+		return err
+	}
+
+	clientOptions, err := config.GitHubClientOptions(cmd.Root().PersistentFlags(), cfg)
+	if err != nil {
+		return err
+	}
+	quota := httpclient.NewQuota()
+	client, err := httpclient.NewGitHubClient(creds.Token, append(clientOptions, httpclient.WithAppCredentials(creds.App), httpclient.WithQuota(quota))...)
+	if err != nil {
+		return fmt.Errorf("could not create GitHub client: %w", err)
+	}
+	defer log.Printf("GitHub API quota used: %s", quota)
+
+	numOrgs := 0
+	numProblems := 0
+	for _, org := range cfg.Organizations {
+		if org.Components == nil {
+			continue
+		}
+		numOrgs++
+
+		problems, err := validateLists(cmd, client, host, org.Name, *org.Components, creds)
+		if err != nil {
+			return fmt.Errorf("organization %q: %w", org.Name, err)
+		}
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		numProblems += len(problems)
+	}
+
+	if numOrgs == 0 {
+		return errors.New("no organization with components configured")
+	}
+	if numProblems > 0 {
+		return fmt.Errorf("found %d problems in repository lists", numProblems)
+	}
+
+	log.Println("Repository lists are valid")
+	return nil
+}
+
+// validateLists validates the repository lists of one organization. client
+// may be nil, then a new client is created if needed.
+func validateLists(cmd *cobra.Command, client *github.Client, host githubhost.Host, organization string, settings config.Components, creds httpclient.Credentials) ([]repositories.ListError, error) {
+	var err error
+	for _, f := range []struct {
+		name  string
+		value *string
+	}{
+		{"repositories-dir", &settings.RepositoriesDir},
+		{"lists-schema-file", &settings.ListsSchemaFile},
+		{"lists-schema-path", &settings.ListsSchemaPath},
+	} {
+		*f.value, err = config.String(cmd.Flags(), f.name, *f.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	offline := false
+	if creds.IsZero() {
+		if settings.RepositoriesDir == "" {
+			return nil, errors.New("please set environment variable GITHUB_TOKEN to a personal GitHub access token (PAT), configure a GitHub App, or use --repositories-dir")
+		}
+		offline = true
+	}
+
+	repoService, err := repositories.New(repositories.Config{
+		GithubOrganization:   organization,
+		GithubRepositoryName: settings.ListsRepository,
+		GithubAuthToken:      creds.Token,
+		GithubClient:         client,
+		GithubHost:           host,
+		DirectoryPath:        settings.ListsPath,
+		LocalDirectoryPath:   settings.RepositoriesDir,
+		SchemaPath:           settings.ListsSchemaFile,
+		SchemaRepositoryPath: settings.ListsSchemaPath,
+		ListsOnly:            true,
+		Offline:              offline,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repoService.ValidateLists()
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/config"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
+)
+
+func TestValidateLists(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "team-x.yaml"), []byte("- name: foo\n- name: bar\n  lifecycle: prod\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := validateCmd.Flags().Set("repositories-dir", dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = validateCmd.Flags().Set("repositories-dir", "")
+	})
+
+	problems, err := validateLists(validateCmd, nil, "", "giantswarm", config.Components{ListsPath: config.DefaultListsPath}, httpclient.Credentials{})
+	if err != nil {
+		t.Fatalf("validateLists() unexpected error %v", err)
+	}
+	if len(problems) != 1 || problems[0].Repo != "bar" || problems[0].Line != 3 {
+		t.Errorf("validateLists() = %v, want one problem in repository bar, line 3", problems)
+	}
+}
@@ -0,0 +1,456 @@
+// Package config provides the configuration file driving all commands.
+//
+// Settings given via command line flags take precedence over the
+// configuration file. Settings neither given via flags nor via the
+// configuration file get the defaults defined here.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/giantswarm/microerror"
+	"go.yaml.in/yaml/v3"
+)
+
+// Defaults
+const (
+	DefaultOutput = "."
+
+	DefaultOrganization = "giantswarm"
+	DefaultNamespace    = "default"
+
+	DefaultListsRepository    = "github"
+	DefaultListsPath          = "repositories"
+	DefaultChartRepoPrefix    = "charts/giantswarm"
+	DefaultPublicOCIRegistry  = "gsoci.azurecr.io"
+	DefaultPrivateOCIRegistry = "gsociprivate.azurecr.io"
+	DefaultChartRepository    = "https://giantswarm.github.io/"
+
+	DefaultInstallationsRepository = "installations"
+
+	DefaultChartsComponentType = "service"
+
+	DefaultComponentsOutputFile    = "components.yaml"
+	DefaultSystemsOutputFile       = "systems.yaml"
+	DefaultDomainsOutputFile       = "domains.yaml"
+	DefaultGroupsOutputFile        = "groups.yaml"
+	DefaultInstallationsOutputFile = "installations.yaml"
+	DefaultUsersOutputFile         = "users.yaml"
+	DefaultChartsOutputFile        = "charts.yaml"
+	DefaultChartAPIsOutputFile     = "chart-apis.yaml"
+	DefaultCRDsOutputFile          = "crds.yaml"
+
+	DefaultChartsDocsDir = "chart-docs"
+)
+
+// Names of the exporters, as used by the all command.
+const (
+	ExporterComponents    = "components"
+	ExporterGroups        = "groups"
+	ExporterInstallations = "installations"
+	ExporterUsers         = "users"
+	ExporterCharts        = "charts"
+	ExporterCRD           = "crd"
+)
+
+// Exporters lists all exporter names in the order they are run.
+var Exporters = []string{
+	ExporterComponents,
+	ExporterGroups,
+	ExporterInstallations,
+	ExporterUsers,
+	ExporterCharts,
+	ExporterCRD,
+}
+
+// Config is the content of a configuration file.
+type Config struct {
+	// Output is the directory to write output files to.
+	Output string `yaml:"output"`
+
+	// CacheDir is the directory of the on-disk cache of GitHub API
+	// responses. If empty, a directory within the user's cache directory
+	// is used.
+	CacheDir string `yaml:"cacheDir"`
+
+	// GitHubHost is the host name of the GitHub instance to use, e.g. of a
+	// GitHub Enterprise Server. Defaults to github.com.
+	GitHubHost string `yaml:"githubHost"`
+
+	// Organizations to export data from. The root, groups, and
+	// installations commands handle every organization having the
+	// respective section.
+	Organizations []Organization `yaml:"organizations"`
+
+	// Users configures the users command. Never nil after loading.
+	Users *Users `yaml:"users"`
+
+	// Charts configures the charts command. Never nil after loading.
+	Charts *Charts `yaml:"charts"`
+
+	// CRD configures the crd command. Never nil after loading.
+	CRD *CRD `yaml:"crd"`
+
+	// Names of the exporters having a section in the configuration.
+	configured []string
+}
+
+// Organization configures the export from one GitHub organization.
+type Organization struct {
+	// Name of the GitHub organization (required).
+	Name string `yaml:"name"`
+
+	// Components configures the root command.
+	Components *Components `yaml:"components"`
+
+	// Groups configures the groups command.
+	Groups *Groups `yaml:"groups"`
+
+	// Installations configures the installations command.
+	Installations *Installations `yaml:"installations"`
+}
+
+// Components configures the export of components, systems and domains
+// based on repository lists.
+type Components struct {
+	// Repository of the organization holding the repository lists.
+	ListsRepository string `yaml:"listsRepository"`
+
+	// Directory path within ListsRepository holding the repository lists.
+	ListsPath string `yaml:"listsPath"`
+
+	// Namespace of the exported entities.
+	Namespace string `yaml:"namespace"`
+
+	// OCI repository prefix of Helm charts.
+	ChartRepoPrefix string `yaml:"chartRepoPrefix"`
+
+	// Host names of the OCI registries for public and private charts.
+	PublicOCIRegistry  string `yaml:"publicOciRegistry"`
+	PrivateOCIRegistry string `yaml:"privateOciRegistry"`
+
+	// URL prefixes of further Helm chart repositories publishing the
+	// organization's charts.
+	ChartRepositories []string `yaml:"chartRepositories"`
+
+	// Paths of the optional rules and systems configuration files.
+	Rules         string `yaml:"rules"`
+	SystemsConfig string `yaml:"systemsConfig"`
+
+	// Local repository lists directory and details snapshot, for offline use.
+	RepositoriesDir     string `yaml:"repositoriesDir"`
+	RepositoriesDetails string `yaml:"repositoriesDetails"`
+
+	// JSON schema to validate repository lists against, instead of the
+	// built-in one: either a local file, or a path within ListsRepository.
+	ListsSchemaFile string `yaml:"listsSchemaFile"`
+	ListsSchemaPath string `yaml:"listsSchemaPath"`
+
+	// If true, list entries of repositories that are missing, archived or
+	// renamed on GitHub fail the export instead of being skipped.
+	Strict bool `yaml:"strict"`
+
+	// If true, a subcomponent is created for every Helm chart of
+	// repositories with more than one chart.
+	ChartSubcomponents bool `yaml:"chartSubcomponents"`
+
+	// Output file names, relative to the output directory.
+	OutputFile        string `yaml:"outputFile"`
+	SystemsOutputFile string `yaml:"systemsOutputFile"`
+	DomainsOutputFile string `yaml:"domainsOutputFile"`
+}
+
+// Groups configures the export of teams as groups.
+type Groups struct {
+	// Allowlist of team slugs to export.
+	Teams []string `yaml:"teams"`
+
+	// Only export descendants of this team.
+	Parent string `yaml:"parent"`
+
+	// Namespace of the exported groups. An empty string omits the
+	// namespace. Defaults to DefaultNamespace if not set.
+	Namespace *string `yaml:"namespace"`
+
+	// Output file name, relative to the output directory.
+	OutputFile string `yaml:"outputFile"`
+}
+
+// Installations configures the export of installations.
+type Installations struct {
+	// Repository of the organization holding installation data.
+	Repository string `yaml:"repository"`
+
+	// Path of the optional rules file.
+	Rules string `yaml:"rules"`
+
+	// Output file name, relative to the output directory.
+	OutputFile string `yaml:"outputFile"`
+}
+
+// Users configures the export of users.
+type Users struct {
+	// Whether to create an internal catalog, including email addresses.
+	Internal bool `yaml:"internal"`
+
+	// Output file name, relative to the output directory.
+	OutputFile string `yaml:"outputFile"`
+}
+
+// Charts configures the export of components from an OCI registry.
+type Charts struct {
+	// Host name of the OCI registry.
+	Registry string `yaml:"registry"`
+
+	// Repository prefix to filter charts.
+	Prefix string `yaml:"prefix"`
+
+	// Namespace and type of the exported components.
+	Namespace string `yaml:"namespace"`
+	Type      string `yaml:"type"`
+
+	// Output file names of components and of the APIs of their values
+	// schemas, relative to the output directory.
+	OutputFile     string `yaml:"outputFile"`
+	APIsOutputFile string `yaml:"apisOutputFile"`
+
+	// Directory to write TechDocs of charts with a README to, relative to
+	// the output directory. An empty string disables writing TechDocs.
+	// Defaults to DefaultChartsDocsDir if not set.
+	DocsDir *string `yaml:"docsDir"`
+}
+
+// CRD configures the export of CRDs as APIs.
+type CRD struct {
+	// Path of the CRD configuration file.
+	Config string `yaml:"config"`
+
+	// Namespace of the exported API entities.
+	Namespace string `yaml:"namespace"`
+
+	// Output file name, relative to the output directory.
+	OutputFile string `yaml:"outputFile"`
+}
+
+// Load reads, parses and validates the configuration file at the given
+// path, and applies defaults.
+func Load(path string) (*Config, error) {
+	file, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, microerror.Maskf(fileNotFoundError, "failed to open config file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	return Read(file)
+}
+
+// Read parses and validates configuration from the given reader, and
+// applies defaults.
+func Read(r io.Reader) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, microerror.Maskf(readError, "failed to read config: %v", err)
+	}
+
+	var c Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&c); err != nil && err != io.EOF {
+		return nil, microerror.Maskf(parseError, "failed to parse YAML: %v", err)
+	}
+
+	c.configured = c.sections()
+	c.setDefaults()
+
+	if err := c.validate(); err != nil {
+		return nil, microerror.Maskf(validationError, "%v", err)
+	}
+
+	return &c, nil
+}
+
+// Default returns the configuration used if no configuration file is
+// given: the default organization with all sections, with defaults applied.
+func Default() *Config {
+	c := &Config{
+		Organizations: []Organization{
+			{
+				Name:          DefaultOrganization,
+				Components:    &Components{},
+				Groups:        &Groups{},
+				Installations: &Installations{},
+			},
+		},
+		Users:  &Users{},
+		Charts: &Charts{},
+		CRD:    &CRD{},
+	}
+	c.configured = c.sections()
+	c.setDefaults()
+	return c
+}
+
+// ConfiguredExporters returns the names of the exporters that have a
+// section in the configuration, in the order of Exporters. For the default
+// configuration, these are all exporters.
+func (c *Config) ConfiguredExporters() []string {
+	return c.configured
+}
+
+// sections returns the names of the exporters with a non-nil section.
+func (c *Config) sections() []string {
+	present := map[string]bool{
+		ExporterUsers:  c.Users != nil,
+		ExporterCharts: c.Charts != nil,
+		ExporterCRD:    c.CRD != nil,
+	}
+	for _, o := range c.Organizations {
+		present[ExporterComponents] = present[ExporterComponents] || o.Components != nil
+		present[ExporterGroups] = present[ExporterGroups] || o.Groups != nil
+		present[ExporterInstallations] = present[ExporterInstallations] || o.Installations != nil
+	}
+
+	var names []string
+	for _, name := range Exporters {
+		if present[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (c *Config) setDefaults() {
+	if c.Output == "" {
+		c.Output = DefaultOutput
+	}
+
+	for i := range c.Organizations {
+		o := &c.Organizations[i]
+		if o.Components != nil {
+			setDefault(&o.Components.ListsRepository, DefaultListsRepository)
+			setDefault(&o.Components.ListsPath, DefaultListsPath)
+			setDefault(&o.Components.Namespace, DefaultNamespace)
+			setDefault(&o.Components.ChartRepoPrefix, DefaultChartRepoPrefix)
+			setDefault(&o.Components.PublicOCIRegistry, DefaultPublicOCIRegistry)
+			setDefault(&o.Components.PrivateOCIRegistry, DefaultPrivateOCIRegistry)
+			if o.Components.ChartRepositories == nil {
+				o.Components.ChartRepositories = []string{DefaultChartRepository}
+			}
+			setDefault(&o.Components.OutputFile, DefaultComponentsOutputFile)
+			setDefault(&o.Components.SystemsOutputFile, DefaultSystemsOutputFile)
+			setDefault(&o.Components.DomainsOutputFile, DefaultDomainsOutputFile)
+		}
+		if o.Groups != nil {
+			if o.Groups.Namespace == nil {
+				namespace := DefaultNamespace
+				o.Groups.Namespace = &namespace
+			}
+			setDefault(&o.Groups.OutputFile, DefaultGroupsOutputFile)
+		}
+		if o.Installations != nil {
+			setDefault(&o.Installations.Repository, DefaultInstallationsRepository)
+			setDefault(&o.Installations.OutputFile, DefaultInstallationsOutputFile)
+		}
+	}
+
+	// The users, charts, and crd commands are invoked explicitly, so their
+	// sections are always present.
+	if c.Users == nil {
+		c.Users = &Users{}
+	}
+	setDefault(&c.Users.OutputFile, DefaultUsersOutputFile)
+	if c.Charts == nil {
+		c.Charts = &Charts{}
+	}
+	setDefault(&c.Charts.Namespace, DefaultNamespace)
+	setDefault(&c.Charts.Type, DefaultChartsComponentType)
+	setDefault(&c.Charts.OutputFile, DefaultChartsOutputFile)
+	setDefault(&c.Charts.APIsOutputFile, DefaultChartAPIsOutputFile)
+	if c.Charts.DocsDir == nil {
+		docsDir := DefaultChartsDocsDir
+		c.Charts.DocsDir = &docsDir
+	}
+	if c.CRD == nil {
+		c.CRD = &CRD{}
+	}
+	setDefault(&c.CRD.Namespace, DefaultNamespace)
+	setDefault(&c.CRD.OutputFile, DefaultCRDsOutputFile)
+}
+
+func setDefault(value *string, defaultValue string) {
+	if *value == "" {
+		*value = defaultValue
+	}
+}
+
+// validate checks required fields and makes sure that no two exports
+// write to the same output file.
+func (c *Config) validate() error {
+	outputFiles := make(map[string]string)
+	addOutputFile := func(file, usage string) error {
+		if other, exists := outputFiles[file]; exists {
+			return fmt.Errorf("output file %q is used by %s and %s, set distinct outputFile values", file, other, usage)
+		}
+		outputFiles[file] = usage
+		return nil
+	}
+
+	names := make(map[string]bool, len(c.Organizations))
+	for i, o := range c.Organizations {
+		if o.Name == "" {
+			return fmt.Errorf("organization %d: name is required", i+1)
+		}
+		if names[o.Name] {
+			return fmt.Errorf("organization %q: defined more than once", o.Name)
+		}
+		names[o.Name] = true
+
+		if o.Components != nil {
+			usage := fmt.Sprintf("organization %q components", o.Name)
+			for _, file := range []string{o.Components.OutputFile, o.Components.SystemsOutputFile, o.Components.DomainsOutputFile} {
+				if err := addOutputFile(file, usage); err != nil {
+					return err
+				}
+			}
+		}
+		if o.Groups != nil {
+			if err := addOutputFile(o.Groups.OutputFile, fmt.Sprintf("organization %q groups", o.Name)); err != nil {
+				return err
+			}
+		}
+		if o.Installations != nil {
+			if err := addOutputFile(o.Installations.OutputFile, fmt.Sprintf("organization %q installations", o.Name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := addOutputFile(c.Users.OutputFile, "users"); err != nil {
+		return err
+	}
+	if err := addOutputFile(c.Charts.OutputFile, "charts"); err != nil {
+		return err
+	}
+	if err := addOutputFile(c.Charts.APIsOutputFile, "chart APIs"); err != nil {
+		return err
+	}
+	if err := addOutputFile(c.CRD.OutputFile, "crd"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Organization returns the configuration of the named organization, or nil
+// if it is not configured.
+func (c *Config) Organization(name string) *Organization {
+	for i := range c.Organizations {
+		if c.Organizations[i].Name == name {
+			return &c.Organizations[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/microerror"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v90/github"
+	"github.com/spf13/pflag"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
+)
+
+func TestRead(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		input       string
+		want        *Config
+		wantErrType error
+	}{
+		{
+			name: "TwoOrganizations",
+			input: `output: catalog
+organizations:
+- name: giantswarm
+  components:
+    rules: rules.yaml
+  groups:
+    parent: employees
+    namespace: ""
+- name: example
+  components:
+    listsRepository: meta
+    namespace: example
+    chartRepositories: []
+    outputFile: example-components.yaml
+    systemsOutputFile: example-systems.yaml
+    domainsOutputFile: example-domains.yaml
+charts:
+  registry: gsoci.azurecr.io
+`,
+			want: &Config{
+				Output: "catalog",
+				Organizations: []Organization{
+					{
+						Name: "giantswarm",
+						Components: &Components{
+							ListsRepository:    DefaultListsRepository,
+							ListsPath:          DefaultListsPath,
+							Namespace:          DefaultNamespace,
+							ChartRepoPrefix:    DefaultChartRepoPrefix,
+							PublicOCIRegistry:  DefaultPublicOCIRegistry,
+							PrivateOCIRegistry: DefaultPrivateOCIRegistry,
+							ChartRepositories:  []string{DefaultChartRepository},
+							Rules:              "rules.yaml",
+							OutputFile:         DefaultComponentsOutputFile,
+							SystemsOutputFile:  DefaultSystemsOutputFile,
+							DomainsOutputFile:  DefaultDomainsOutputFile,
+						},
+						Groups: &Groups{
+							Parent:     "employees",
+							Namespace:  ptr(""),
+							OutputFile: DefaultGroupsOutputFile,
+						},
+					},
+					{
+						Name: "example",
+						Components: &Components{
+							ListsRepository:    "meta",
+							ListsPath:          DefaultListsPath,
+							Namespace:          "example",
+							ChartRepoPrefix:    DefaultChartRepoPrefix,
+							PublicOCIRegistry:  DefaultPublicOCIRegistry,
+							PrivateOCIRegistry: DefaultPrivateOCIRegistry,
+							ChartRepositories:  []string{},
+							OutputFile:         "example-components.yaml",
+							SystemsOutputFile:  "example-systems.yaml",
+							DomainsOutputFile:  "example-domains.yaml",
+						},
+					},
+				},
+				Users: &Users{OutputFile: DefaultUsersOutputFile},
+				Charts: &Charts{
+					Registry:       "gsoci.azurecr.io",
+					Namespace:      DefaultNamespace,
+					Type:           DefaultChartsComponentType,
+					OutputFile:     DefaultChartsOutputFile,
+					APIsOutputFile: DefaultChartAPIsOutputFile,
+					DocsDir:        ptr(DefaultChartsDocsDir),
+				},
+				CRD:        &CRD{Namespace: DefaultNamespace, OutputFile: DefaultCRDsOutputFile},
+				configured: []string{ExporterComponents, ExporterGroups, ExporterCharts},
+			},
+		},
+		{
+			name:  "Empty",
+			input: ``,
+			want: &Config{
+				Output: DefaultOutput,
+				Users:  &Users{OutputFile: DefaultUsersOutputFile},
+				Charts: &Charts{Namespace: DefaultNamespace, Type: DefaultChartsComponentType, OutputFile: DefaultChartsOutputFile, APIsOutputFile: DefaultChartAPIsOutputFile, DocsDir: ptr(DefaultChartsDocsDir)},
+				CRD:    &CRD{Namespace: DefaultNamespace, OutputFile: DefaultCRDsOutputFile},
+			},
+		},
+		{
+			name: "ChartsDocsDisabled",
+			input: `charts:
+  docsDir: ""
+`,
+			want: &Config{
+				Output:     DefaultOutput,
+				Users:      &Users{OutputFile: DefaultUsersOutputFile},
+				Charts:     &Charts{Namespace: DefaultNamespace, Type: DefaultChartsComponentType, OutputFile: DefaultChartsOutputFile, APIsOutputFile: DefaultChartAPIsOutputFile, DocsDir: ptr("")},
+				CRD:        &CRD{Namespace: DefaultNamespace, OutputFile: DefaultCRDsOutputFile},
+				configured: []string{ExporterCharts},
+			},
+		},
+		{
+			name:        "InvalidYAML",
+			input:       `organizations: [`,
+			wantErrType: parseError,
+		},
+		{
+			name: "UnknownField",
+			input: `organizations:
+- name: giantswarm
+  component: {}
+`,
+			wantErrType: parseError,
+		},
+		{
+			name: "OrganizationWithoutName",
+			input: `organizations:
+- groups: {}
+`,
+			wantErrType: validationError,
+		},
+		{
+			name: "DuplicateOrganization",
+			input: `organizations:
+- name: giantswarm
+- name: giantswarm
+`,
+			wantErrType: validationError,
+		},
+		{
+			name: "DuplicateOutputFile",
+			input: `organizations:
+- name: giantswarm
+  components: {}
+- name: example
+  components: {}
+`,
+			wantErrType: validationError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.input))
+			if tt.wantErrType != nil {
+				if microerror.Cause(err) != tt.wantErrType {
+					t.Errorf("Read() error = %v, want %v", err, tt.wantErrType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read() unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(Config{})); diff != "" {
+				t.Errorf("Read() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	c := Default()
+	if len(c.Organizations) != 1 || c.Organizations[0].Name != DefaultOrganization {
+		t.Fatalf("Default() organizations = %v, want only %q", c.Organizations, DefaultOrganization)
+	}
+	if err := c.validate(); err != nil {
+		t.Errorf("Default() is invalid: %v", err)
+	}
+	if c.Organization(DefaultOrganization).Components.OutputFile != DefaultComponentsOutputFile {
+		t.Errorf("Default() components output file = %q, want %q", c.Organizations[0].Components.OutputFile, DefaultComponentsOutputFile)
+	}
+	if c.Organization("example") != nil {
+		t.Errorf("Organization() returned unconfigured organization")
+	}
+	if diff := cmp.Diff(Exporters, c.ConfiguredExporters()); diff != "" {
+		t.Errorf("ConfiguredExporters() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		configValue string
+		want        string
+	}{
+		{name: "FlagDefault", configValue: "", want: "default"},
+		{name: "ConfigValue", configValue: "config", want: "config"},
+		{name: "ExplicitFlag", args: []string{"--value", "flag"}, configValue: "config", want: "flag"},
+		{name: "ExplicitDefault", args: []string{"--value", "default"}, configValue: "config", want: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			flags.String("value", "default", "")
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+
+			got, err := String(flags, "value", tt.configValue)
+			if err != nil {
+				t.Fatalf("String() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGitHubClientOptions makes two requests with a client created from the
+// options, and checks whether the second one is revalidated from the disk
+// cache in the expected directory.
+func TestGitHubClientOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		cacheDir string
+		// Expected cache directory, relative to the working directory, or
+		// "default" for the user's cache directory. Empty if not cached.
+		wantCacheDir string
+	}{
+		{name: "Default", wantCacheDir: "default"},
+		{name: "Configured", cacheDir: "configured", wantCacheDir: "configured"},
+		{name: "Flag", args: []string{"--cache-dir", "flag"}, cacheDir: "configured", wantCacheDir: "flag"},
+		{name: "NoCache", args: []string{"--no-cache"}, cacheDir: "configured"},
+		{name: "Replay", args: []string{"--replay", "testdata"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+			revalidated := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					revalidated = true
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", `"v1"`)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"login": "alice"}`))
+			}))
+			defer server.Close()
+
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			flags.String(CacheDirFlagName, "", "")
+			flags.Bool(NoCacheFlagName, false, "")
+			flags.String(GitHubHostFlagName, "", "")
+			flags.String(ReplayFlagName, "", "")
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+
+			options, err := GitHubClientOptions(flags, &Config{CacheDir: tt.cacheDir})
+			if err != nil {
+				t.Fatalf("GitHubClientOptions() unexpected error: %v", err)
+			}
+			client, err := httpclient.NewGitHubClient("token", options...)
+			if err != nil {
+				t.Fatalf("NewGitHubClient() unexpected error: %v", err)
+			}
+			baseURL := server.URL + "/"
+			client, err = client.Clone(github.WithURLs(&baseURL, &baseURL))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for range 2 {
+				user, _, err := client.Users.Get(context.Background(), "alice")
+				if err != nil {
+					t.Fatalf("Users.Get() unexpected error: %v", err)
+				}
+				if user.GetLogin() != "alice" {
+					t.Errorf("Users.Get() login = %q, want alice", user.GetLogin())
+				}
+			}
+
+			if want := tt.wantCacheDir != ""; revalidated != want {
+				t.Errorf("second request revalidated = %v, want %v", revalidated, want)
+			}
+			if tt.wantCacheDir == "" {
+				return
+			}
+
+			dir := tt.wantCacheDir
+			if dir == "default" {
+				dir, err = httpclient.DefaultCacheDir()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil || len(entries) == 0 {
+				t.Errorf("cache directory %s has no entries, error %v", dir, err)
+			}
+		})
+	}
+}
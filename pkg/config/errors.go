@@ -0,0 +1,19 @@
+package config
+
+import "github.com/giantswarm/microerror"
+
+var fileNotFoundError = &microerror.Error{
+	Kind: "fileNotFoundError",
+}
+
+var readError = &microerror.Error{
+	Kind: "readError",
+}
+
+var parseError = &microerror.Error{
+	Kind: "parseError",
+}
+
+var validationError = &microerror.Error{
+	Kind: "validationError",
+}
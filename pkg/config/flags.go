@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
+	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
+)
+
+// String returns the value of the named flag if it was set explicitly or if
+// configValue is empty, otherwise configValue.
+func String(flags *pflag.FlagSet, name, configValue string) (string, error) {
+	value, err := flags.GetString(name)
+	if err != nil {
+		return "", err
+	}
+	if flags.Changed(name) || configValue == "" {
+		return value, nil
+	}
+	return configValue, nil
+}
+
+// StringSlice returns the value of the named flag if it was set explicitly
+// or if configValue is empty, otherwise configValue.
+func StringSlice(flags *pflag.FlagSet, name string, configValue []string) ([]string, error) {
+	value, err := flags.GetStringSlice(name)
+	if err != nil {
+		return nil, err
+	}
+	if flags.Changed(name) || len(configValue) == 0 {
+		return value, nil
+	}
+	return configValue, nil
+}
+
+// Bool returns the value of the named flag if it was set explicitly,
+// otherwise configValue.
+func Bool(flags *pflag.FlagSet, name string, configValue bool) (bool, error) {
+	value, err := flags.GetBool(name)
+	if err != nil {
+		return false, err
+	}
+	if flags.Changed(name) {
+		return value, nil
+	}
+	return configValue, nil
+}
+
+// FlagName is the name of the root command's persistent flag giving the
+// path of the configuration file.
+const FlagName = "config"
+
+// FromFlags loads the configuration file given via the FlagName flag, or
+// returns the default configuration if the flag is empty.
+func FromFlags(flags *pflag.FlagSet) (*Config, error) {
+	path, err := flags.GetString(FlagName)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return Default(), nil
+	}
+	return Load(path)
+}
+
+// Names of the root command's persistent flags configuring the on-disk
+// cache of GitHub API responses.
+const (
+	CacheDirFlagName = "cache-dir"
+	NoCacheFlagName  = "no-cache"
+)
+
+// Names of the root command's persistent flags recording or replaying HTTP
+// interactions.
+const (
+	RecordFlagName = "record"
+	ReplayFlagName = "replay"
+)
+
+// RecordOrReplay records HTTP interactions to the directory given via the
+// RecordFlagName flag, or replays them from the directory given via the
+// ReplayFlagName flag. Must be called before creating any clients.
+func RecordOrReplay(flags *pflag.FlagSet) error {
+	recordDir, err := flags.GetString(RecordFlagName)
+	if err != nil {
+		return err
+	}
+	replayDir, err := flags.GetString(ReplayFlagName)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case recordDir != "" && replayDir != "":
+		return fmt.Errorf("--%s and --%s are mutually exclusive", RecordFlagName, ReplayFlagName)
+	case recordDir != "":
+		_, err = httpclient.Record(recordDir)
+	case replayDir != "":
+		_, err = httpclient.Replay(replayDir)
+	}
+	return err
+}
+
+// GitHubHostFlagName is the name of the root command's persistent flag
+// giving the GitHub host.
+const GitHubHostFlagName = "github-host"
+
+// GitHubHost returns the GitHub host according to the flag and
+// configuration.
+func GitHubHost(flags *pflag.FlagSet, c *Config) (githubhost.Host, error) {
+	name, err := String(flags, GitHubHostFlagName, c.GitHubHost)
+	if err != nil {
+		return "", err
+	}
+	return githubhost.New(name)
+}
+
+// GitHubClientOptions returns the options for creating GitHub clients
+// according to the GitHub host and cache flags and configuration. The
+// on-disk cache is not used while recording or replaying HTTP interactions,
+// so that recordings are complete and replays reproducible.
+func GitHubClientOptions(flags *pflag.FlagSet, c *Config) ([]httpclient.Option, error) {
+	host, err := GitHubHost(flags, c)
+	if err != nil {
+		return nil, err
+	}
+	options := []httpclient.Option{httpclient.WithHost(host)}
+
+	noCache, err := flags.GetBool(NoCacheFlagName)
+	if err != nil {
+		return nil, err
+	}
+	if noCache || flags.Changed(RecordFlagName) || flags.Changed(ReplayFlagName) {
+		return options, nil
+	}
+
+	dir, err := String(flags, CacheDirFlagName, c.CacheDir)
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		dir, err = httpclient.DefaultCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("could not determine cache directory, use --%s or --%s: %w", CacheDirFlagName, NoCacheFlagName, err)
+		}
+	}
+
+	return append(options, httpclient.WithDiskCache(dir)), nil
+}
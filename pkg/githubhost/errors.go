@@ -0,0 +1,7 @@
+package githubhost
+
+import "github.com/giantswarm/microerror"
+
+var invalidHostError = &microerror.Error{
+	Kind: "invalidHostError",
+}
@@ -0,0 +1,121 @@
+// Package githubhost describes the GitHub instance to work with, either
+// github.com or a GitHub Enterprise Server (GHES), and builds its URLs.
+package githubhost
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+// Default is the name of the public GitHub host.
+const Default = "github.com"
+
+// Host is a GitHub instance, given by its host name. The empty host is
+// github.com, as is Default.
+type Host string
+
+// New returns the host with the given name, e.g. "github.example.com". A
+// URL like "https://github.example.com/" is accepted as well. An empty name
+// results in github.com.
+func New(name string) (Host, error) {
+	name = strings.TrimSpace(name)
+	name = strings.TrimPrefix(name, "https://")
+	name = strings.TrimSuffix(name, "/")
+	if name == "" || name == Default {
+		return "", nil
+	}
+	if strings.ContainsAny(name, "/:?# ") {
+		return "", microerror.Maskf(invalidHostError, "GitHub host must be a host name, got %q", name)
+	}
+	return Host(name), nil
+}
+
+// Name returns the host name, e.g. "github.com".
+func (h Host) Name() string {
+	if h == "" {
+		return Default
+	}
+	return string(h)
+}
+
+// String returns the host name.
+func (h Host) String() string {
+	return h.Name()
+}
+
+// IsEnterprise returns whether the host is a GitHub Enterprise Server.
+func (h Host) IsEnterprise() bool {
+	return h.Name() != Default
+}
+
+// WebURL returns the base URL of the web interface, with a trailing slash.
+func (h Host) WebURL() string {
+	return fmt.Sprintf("https://%s/", h.Name())
+}
+
+// APIURL returns the base URL of the REST API, with a trailing slash.
+func (h Host) APIURL() string {
+	if !h.IsEnterprise() {
+		return "https://api.github.com/"
+	}
+	return fmt.Sprintf("https://%s/api/v3/", h.Name())
+}
+
+// GraphQLURL returns the URL of the GraphQL API.
+func (h Host) GraphQLURL() string {
+	if !h.IsEnterprise() {
+		return "https://api.github.com/graphql"
+	}
+	return fmt.Sprintf("https://%s/api/graphql", h.Name())
+}
+
+// UploadURL returns the base URL for uploads, with a trailing slash.
+func (h Host) UploadURL() string {
+	if !h.IsEnterprise() {
+		return "https://uploads.github.com/"
+	}
+	return fmt.Sprintf("https://%s/api/uploads/", h.Name())
+}
+
+// RepoURL returns the URL of a repository given as "<owner>/<repository>".
+func (h Host) RepoURL(slug string) string {
+	return h.WebURL() + slug
+}
+
+// TreeURL returns the URL of a directory in a repository at the given ref.
+// An empty path refers to the root directory.
+func (h Host) TreeURL(slug, ref, path string) string {
+	url := fmt.Sprintf("%s/tree/%s", h.RepoURL(slug), ref)
+	if path != "" {
+		url += "/" + path
+	}
+	return url
+}
+
+// BlobURL returns the URL of a file in a repository at the given ref.
+func (h Host) BlobURL(slug, ref, path string) string {
+	return fmt.Sprintf("%s/blob/%s/%s", h.RepoURL(slug), ref, path)
+}
+
+// TeamAvatarURL returns the URL of the avatar image of a team. For GitHub
+// Enterprise Server, subdomain isolation is assumed.
+func (h Host) TeamAvatarURL(id int64, size int) string {
+	base := "https://avatars.githubusercontent.com/"
+	if h.IsEnterprise() {
+		base = fmt.Sprintf("https://avatars.%s/", h.Name())
+	}
+	return fmt.Sprintf("%st/%d?s=%d&v=4", base, id, size)
+}
+
+// RawURLPrefixes returns the prefixes of raw file URLs, without scheme,
+// each followed by "<owner>/<repository>/<ref>/<path>". For GitHub
+// Enterprise Server, both forms with and without subdomain isolation are
+// returned.
+func (h Host) RawURLPrefixes() []string {
+	if !h.IsEnterprise() {
+		return []string{"raw.githubusercontent.com/"}
+	}
+	return []string{"raw." + h.Name() + "/", h.Name() + "/raw/"}
+}
@@ -0,0 +1,111 @@
+package githubhost
+
+import (
+	"testing"
+
+	"github.com/giantswarm/microerror"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		wantName       string
+		wantEnterprise bool
+		wantErr        bool
+	}{
+		{name: "Empty", input: "", wantName: "github.com"},
+		{name: "Default", input: "github.com", wantName: "github.com"},
+		{name: "Enterprise", input: "github.example.com", wantName: "github.example.com", wantEnterprise: true},
+		{name: "EnterpriseURL", input: "https://github.example.com/", wantName: "github.example.com", wantEnterprise: true},
+		{name: "WithPath", input: "https://github.example.com/api/v3", wantErr: true},
+		{name: "WithPort", input: "github.example.com:8443", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.input)
+			if tt.wantErr {
+				if microerror.Cause(err) != invalidHostError {
+					t.Errorf("New() error = %v, want %v", err, invalidHostError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() unexpected error: %v", err)
+			}
+			if got.Name() != tt.wantName {
+				t.Errorf("New().Name() = %q, want %q", got.Name(), tt.wantName)
+			}
+			if got.IsEnterprise() != tt.wantEnterprise {
+				t.Errorf("New().IsEnterprise() = %v, want %v", got.IsEnterprise(), tt.wantEnterprise)
+			}
+		})
+	}
+}
+
+func TestURLs(t *testing.T) {
+	enterprise, err := New("github.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		host Host
+		want []string
+	}{
+		{
+			name: "Default",
+			host: "",
+			want: []string{
+				"https://github.com/",
+				"https://api.github.com/",
+				"https://api.github.com/graphql",
+				"https://uploads.github.com/",
+				"https://github.com/giantswarm/foo",
+				"https://github.com/giantswarm/foo/tree/main",
+				"https://github.com/giantswarm/foo/tree/main/docs",
+				"https://github.com/giantswarm/foo/blob/main/docs/README.md",
+				"https://avatars.githubusercontent.com/t/42?s=116&v=4",
+				"raw.githubusercontent.com/",
+			},
+		},
+		{
+			name: "Enterprise",
+			host: enterprise,
+			want: []string{
+				"https://github.example.com/",
+				"https://github.example.com/api/v3/",
+				"https://github.example.com/api/graphql",
+				"https://github.example.com/api/uploads/",
+				"https://github.example.com/giantswarm/foo",
+				"https://github.example.com/giantswarm/foo/tree/main",
+				"https://github.example.com/giantswarm/foo/tree/main/docs",
+				"https://github.example.com/giantswarm/foo/blob/main/docs/README.md",
+				"https://avatars.github.example.com/t/42?s=116&v=4",
+				"raw.github.example.com/",
+				"github.example.com/raw/",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{
+				tt.host.WebURL(),
+				tt.host.APIURL(),
+				tt.host.GraphQLURL(),
+				tt.host.UploadURL(),
+				tt.host.RepoURL("giantswarm/foo"),
+				tt.host.TreeURL("giantswarm/foo", "main", ""),
+				tt.host.TreeURL("giantswarm/foo", "main", "docs"),
+				tt.host.BlobURL("giantswarm/foo", "main", "docs/README.md"),
+				tt.host.TeamAvatarURL(42, 116),
+			}
+			got = append(got, tt.host.RawURLPrefixes()...)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("URLs mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
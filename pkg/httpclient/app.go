@@ -0,0 +1,250 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Environment variables holding credentials.
+const (
+	TokenEnvVar             = "GITHUB_TOKEN"
+	AppIDEnvVar             = "GITHUB_APP_ID"
+	AppInstallationIDEnvVar = "GITHUB_APP_INSTALLATION_ID"
+	AppPrivateKeyEnvVar     = "GITHUB_APP_PRIVATE_KEY"
+	AppPrivateKeyFileEnvVar = "GITHUB_APP_PRIVATE_KEY_FILE"
+)
+
+// Installation tokens are valid for one hour. They are refreshed if they
+// expire within this duration.
+const installationTokenRefreshMargin = 5 * time.Minute
+
+// Credentials to authenticate against the GitHub API with: either a
+// personal access token, or the credentials of a GitHub App installation.
+type Credentials struct {
+	Token string
+	App   *AppCredentials
+}
+
+// AppCredentials identify a GitHub App installation. The client acts as the
+// installation, using installation access tokens that are created and
+// refreshed as needed.
+type AppCredentials struct {
+	AppID          int64
+	InstallationID int64
+
+	// PEM encoded private key of the app.
+	PrivateKey []byte
+}
+
+// IsZero returns true if no credentials are given.
+func (c Credentials) IsZero() bool {
+	return c.Token == "" && c.App == nil
+}
+
+// CredentialsFromEnv reads credentials from the GITHUB_TOKEN environment
+// variable or, if not set, the GitHub App credentials from GITHUB_APP_ID,
+// GITHUB_APP_INSTALLATION_ID, and GITHUB_APP_PRIVATE_KEY_FILE or
+// GITHUB_APP_PRIVATE_KEY. Returns empty credentials if none are set.
+func CredentialsFromEnv() (Credentials, error) {
+	if token := os.Getenv(TokenEnvVar); token != "" {
+		return Credentials{Token: token}, nil
+	}
+	if os.Getenv(AppIDEnvVar) == "" {
+		return Credentials{}, nil
+	}
+
+	appID, err := strconv.ParseInt(os.Getenv(AppIDEnvVar), 10, 64)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("invalid %s: %w", AppIDEnvVar, err)
+	}
+	installationID, err := strconv.ParseInt(os.Getenv(AppInstallationIDEnvVar), 10, 64)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("invalid %s: %w", AppInstallationIDEnvVar, err)
+	}
+
+	key := []byte(os.Getenv(AppPrivateKeyEnvVar))
+	if path := os.Getenv(AppPrivateKeyFileEnvVar); path != "" {
+		key, err = os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return Credentials{}, fmt.Errorf("could not read GitHub App private key: %w", err)
+		}
+	}
+	if len(key) == 0 {
+		return Credentials{}, fmt.Errorf("%s or %s must be set for GitHub App authentication", AppPrivateKeyFileEnvVar, AppPrivateKeyEnvVar)
+	}
+
+	return Credentials{App: &AppCredentials{AppID: appID, InstallationID: installationID, PrivateKey: key}}, nil
+}
+
+// WithAppCredentials authenticates as a GitHub App installation instead of
+// with a token. Does nothing if creds is nil.
+func WithAppCredentials(creds *AppCredentials) Option {
+	return func(o *clientOptions) {
+		o.app = creds
+	}
+}
+
+// parsePrivateKey parses a PEM encoded RSA private key, as generated by
+// GitHub in PKCS #1 format, or in PKCS #8 format.
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found in GitHub App private key")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse GitHub App private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// appTransport is an http.RoundTripper that authenticates requests with an
+// installation access token of a GitHub App. The token is created using a
+// JWT signed with the app's private key, and refreshed before it expires.
+type appTransport struct {
+	base    http.RoundTripper
+	creds   AppCredentials
+	key     *rsa.PrivateKey
+	baseURL string
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func newAppTransport(base http.RoundTripper, creds AppCredentials, baseURL string) (*appTransport, error) {
+	key, err := parsePrivateKey(creds.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &appTransport{
+		base:    base,
+		creds:   creds,
+		key:     key,
+		baseURL: baseURL,
+	}, nil
+}
+
+// identity describes whom requests are made as, without secrets.
+func (t *appTransport) identity() string {
+	return fmt.Sprintf("app %d installation %d", t.creds.AppID, t.creds.InstallationID)
+}
+
+// RoundTrip adds a valid installation access token to the request.
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.installationToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	// Only modify a copy of the request, as required for RoundTrippers.
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+token)
+
+	return t.base.RoundTrip(req)
+}
+
+// installationToken returns the current installation access token, creating
+// a new one if it expires soon.
+func (t *appTransport) installationToken(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Until(t.expiresAt) > installationTokenRefreshMargin {
+		return t.token, nil
+	}
+
+	jwt, err := t.jwt(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%sapp/installations/%d/access_tokens", t.baseURL, t.creds.InstallationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return "", fmt.Errorf("could not create GitHub App installation token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("could not create GitHub App installation token: %s", resp.Status)
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return "", fmt.Errorf("could not decode GitHub App installation token: %w", err)
+	}
+
+	t.token = result.Token
+	t.expiresAt = result.ExpiresAt
+
+	return t.token, nil
+}
+
+// jwt returns a JSON Web Token authenticating as the app, valid for ten
+// minutes at most, as allowed by GitHub. The issue time is set in the past
+// to allow for clock drift.
+func (t *appTransport) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(t.creds.AppID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	b.WriteString(base64.RawURLEncoding.EncodeToString(header))
+	b.WriteByte('.')
+	b.WriteString(base64.RawURLEncoding.EncodeToString(claims))
+
+	digest := sha256.Sum256(b.Bytes())
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("could not sign GitHub App JWT: %w", err)
+	}
+	b.WriteByte('.')
+	b.WriteString(base64.RawURLEncoding.EncodeToString(signature))
+
+	return b.String(), nil
+}
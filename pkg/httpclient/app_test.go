@@ -0,0 +1,192 @@
+package httpclient
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAppTransport(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var tokens atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/app/installations/2/access_tokens" {
+			if r.Method != http.MethodPost {
+				t.Errorf("token request method = %s, want POST", r.Method)
+			}
+			jwt, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				t.Errorf("token request without bearer JWT")
+			}
+			if iss := verifyJWT(t, &key.PublicKey, jwt); iss != "1" {
+				t.Errorf("JWT issuer = %q, want %q", iss, "1")
+			}
+
+			// The first token expires too soon to be reused.
+			n := tokens.Add(1)
+			expiresAt := time.Now().Add(time.Hour)
+			if n == 1 {
+				expiresAt = time.Now().Add(time.Minute)
+			}
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{"token": fmt.Sprintf("ghs_%d", n), "expires_at": expiresAt})
+			return
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	transport, err := newAppTransport(http.DefaultTransport, AppCredentials{AppID: 1, InstallationID: 2, PrivateKey: keyPEM}, server.URL+"/")
+	if err != nil {
+		t.Fatalf("newAppTransport() unexpected error: %v", err)
+	}
+	client := &http.Client{Transport: transport}
+
+	for i, want := range []string{"token ghs_1", "token ghs_2", "token ghs_2", "token ghs_2"} {
+		resp, err := client.Get(server.URL + "/repos/example/foo")
+		if err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i, err)
+		}
+		if string(body) != want {
+			t.Errorf("request %d: Authorization = %q, want %q", i, body, want)
+		}
+	}
+	if got := tokens.Load(); got != 2 {
+		t.Errorf("created %d installation tokens, want 2", got)
+	}
+}
+
+func TestNewAppTransportInvalidKey(t *testing.T) {
+	_, err := newAppTransport(http.DefaultTransport, AppCredentials{AppID: 1, InstallationID: 2, PrivateKey: []byte("not a key")}, "https://api.github.com/")
+	if err == nil {
+		t.Errorf("newAppTransport() expected error for invalid key")
+	}
+}
+
+func TestCredentialsFromEnv(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyFile, []byte("key from file"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Credentials
+		wantErr bool
+	}{
+		{name: "None"},
+		{
+			name: "Token",
+			env:  map[string]string{TokenEnvVar: "ghp_x", AppIDEnvVar: "1"},
+			want: Credentials{Token: "ghp_x"},
+		},
+		{
+			name: "AppKey",
+			env:  map[string]string{AppIDEnvVar: "1", AppInstallationIDEnvVar: "2", AppPrivateKeyEnvVar: "key"},
+			want: Credentials{App: &AppCredentials{AppID: 1, InstallationID: 2, PrivateKey: []byte("key")}},
+		},
+		{
+			name: "AppKeyFile",
+			env:  map[string]string{AppIDEnvVar: "1", AppInstallationIDEnvVar: "2", AppPrivateKeyEnvVar: "key", AppPrivateKeyFileEnvVar: keyFile},
+			want: Credentials{App: &AppCredentials{AppID: 1, InstallationID: 2, PrivateKey: []byte("key from file")}},
+		},
+		{
+			name:    "AppWithoutInstallation",
+			env:     map[string]string{AppIDEnvVar: "1", AppPrivateKeyEnvVar: "key"},
+			wantErr: true,
+		},
+		{
+			name:    "AppWithoutKey",
+			env:     map[string]string{AppIDEnvVar: "1", AppInstallationIDEnvVar: "2"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{TokenEnvVar, AppIDEnvVar, AppInstallationIDEnvVar, AppPrivateKeyEnvVar, AppPrivateKeyFileEnvVar} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			got, err := CredentialsFromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("CredentialsFromEnv() expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CredentialsFromEnv() unexpected error: %v", err)
+			}
+			if got.Token != tt.want.Token || (got.App == nil) != (tt.want.App == nil) {
+				t.Fatalf("CredentialsFromEnv() = %+v, want %+v", got, tt.want)
+			}
+			if got.App != nil && (got.App.AppID != tt.want.App.AppID || got.App.InstallationID != tt.want.App.InstallationID || string(got.App.PrivateKey) != string(tt.want.App.PrivateKey)) {
+				t.Errorf("CredentialsFromEnv() app = %+v, want %+v", got.App, tt.want.App)
+			}
+		})
+	}
+}
+
+// verifyJWT checks the signature of an RS256 JWT and returns its issuer.
+func verifyJWT(t *testing.T, key *rsa.PublicKey, jwt string) string {
+	t.Helper()
+
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Errorf("JWT has %d parts, want 3", len(parts))
+		return ""
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Errorf("could not decode JWT signature: %v", err)
+		return ""
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("invalid JWT signature: %v", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Errorf("could not decode JWT claims: %v", err)
+		return ""
+	}
+	var claims struct {
+		Iss string `json:"iss"`
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Errorf("could not parse JWT claims: %v", err)
+	}
+	if claims.Exp-claims.Iat > 600 {
+		t.Errorf("JWT valid for %ds, GitHub allows at most 600s", claims.Exp-claims.Iat)
+	}
+	return claims.Iss
+}
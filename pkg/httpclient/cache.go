@@ -0,0 +1,66 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+)
+
+// cacheTransport is an http.RoundTripper that keeps successful responses to
+// GET requests in memory, so that repeated lookups of the same resource,
+// e.g. by several exporters, result in one request only.
+type cacheTransport struct {
+	base http.RoundTripper
+
+	mu        sync.Mutex
+	responses map[string][]byte
+}
+
+// cacheKey identifies a request. The Accept header is part of the key, as
+// the GitHub API returns different representations depending on it.
+func cacheKey(req *http.Request) string {
+	return req.Header.Get("Accept") + " " + req.URL.String()
+}
+
+// RoundTrip serves GET requests from the cache if possible, otherwise
+// executes the request and caches the response if successful.
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+
+	t.mu.Lock()
+	dump, ok := t.responses[key]
+	t.mu.Unlock()
+	if ok {
+		return http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	dump, err = httputil.DumpResponse(resp, true)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.responses[key] = dump
+	t.mu.Unlock()
+
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+}
+
+// Len returns the number of cached responses.
+func (t *cacheTransport) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.responses)
+}
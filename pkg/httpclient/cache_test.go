@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCacheTransport(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-Test", "yes")
+		_, _ = io.WriteString(w, "content of "+r.URL.Path)
+	}))
+	defer server.Close()
+
+	transport := &cacheTransport{
+		base:      http.DefaultTransport,
+		responses: make(map[string][]byte),
+	}
+	client := &http.Client{Transport: transport}
+
+	get := func(method, path string) (int, string, string) {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp.StatusCode, string(body), resp.Header.Get("X-Test")
+	}
+
+	for range 3 {
+		code, body, header := get(http.MethodGet, "/a")
+		if code != http.StatusOK || body != "content of /a" || header != "yes" {
+			t.Errorf("got %d %q %q, want cached response", code, body, header)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected 1 request for cached resource, got %d", got)
+	}
+
+	// Errors and other methods are not cached.
+	get(http.MethodGet, "/missing")
+	get(http.MethodGet, "/missing")
+	get(http.MethodPost, "/a")
+	get(http.MethodPost, "/a")
+	if got := requests.Load(); got != 5 {
+		t.Errorf("expected 5 requests in total, got %d", got)
+	}
+	if got := transport.Len(); got != 1 {
+		t.Errorf("expected 1 cached response, got %d", got)
+	}
+}
@@ -0,0 +1,161 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Name of the directory within the user's cache directory holding cached
+// responses by default.
+const cacheDirName = "backstage-catalog-importer"
+
+// DefaultCacheDir returns the directory used for the on-disk response cache
+// if none is configured, within the user's cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, cacheDirName), nil
+}
+
+// diskCacheTransport is an http.RoundTripper that stores responses to GET
+// requests on disk and revalidates them with conditional requests. Servers
+// like the GitHub API answer with 304 Not Modified if the resource hasn't
+// changed, which doesn't count against the rate limit.
+//
+// Responses are keyed by URL, Accept header and Authorization header, so
+// that different identities never share responses.
+type diskCacheTransport struct {
+	base http.RoundTripper
+	dir  string
+
+	// Identity of requests authenticated further down the chain, i.e.
+	// without an Authorization header yet.
+	identity string
+}
+
+// diskCacheKey returns the file name for a request. Credentials are hashed
+// and never written to disk.
+func diskCacheKey(identity string, req *http.Request) string {
+	h := sha256.New()
+	for _, s := range []string{identity, req.Header.Get("Authorization"), req.Header.Get("Accept"), req.URL.String()} {
+		_, _ = io.WriteString(h, s)
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RoundTrip revalidates cached responses to GET requests, serving them from
+// disk if not modified, and stores new responses that can be revalidated.
+func (t *diskCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	path := filepath.Join(t.dir, diskCacheKey(t.identity, req))
+
+	cached, err := t.load(path, req)
+	if err != nil {
+		log.Printf("WARN - could not read cached response for %s: %v", req.URL.Path, err)
+	}
+
+	if cached != nil {
+		// Only modify a copy of the request, as required for RoundTrippers.
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		if cached != nil {
+			_ = cached.Body.Close()
+		}
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		// Rate limit information must be current.
+		for name, values := range resp.Header {
+			if strings.HasPrefix(strings.ToLower(name), "x-ratelimit-") {
+				cached.Header[name] = values
+			}
+		}
+		_ = resp.Body.Close()
+		return cached, nil
+	}
+
+	if cached != nil {
+		_ = cached.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK || (resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "") {
+		return resp, nil
+	}
+
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+
+	err = t.store(path, dump)
+	if err != nil {
+		log.Printf("WARN - could not cache response for %s: %v", req.URL.Path, err)
+	}
+
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+}
+
+// load returns the cached response stored at path, or nil if there is none.
+func (t *diskCacheTransport) load(path string, req *http.Request) (*http.Response, error) {
+	dump, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+}
+
+// store writes the response dump to path. The file is replaced atomically,
+// so that concurrent readers never see partial content.
+func (t *diskCacheTransport) store(path string, dump []byte) error {
+	err := os.MkdirAll(t.dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(t.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	_, err = f.Write(dump)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
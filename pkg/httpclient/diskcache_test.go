@@ -0,0 +1,96 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDiskCacheTransport(t *testing.T) {
+	var full, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		switch r.URL.Path {
+		case "/etag":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+		case "/last-modified":
+			if r.Header.Get("If-Modified-Since") == "Mon, 02 Jan 2006 15:04:05 GMT" {
+				notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		}
+		full.Add(1)
+		_, _ = io.WriteString(w, "content of "+r.URL.Path)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := &http.Client{Transport: &diskCacheTransport{base: http.DefaultTransport, dir: dir}}
+
+	get := func(path, token string) (int, string, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp.StatusCode, string(body), resp.Header.Get("X-RateLimit-Remaining")
+	}
+
+	for _, path := range []string{"/etag", "/last-modified"} {
+		for range 3 {
+			code, body, remaining := get(path, "a")
+			if code != http.StatusOK || body != "content of "+path || remaining != "42" {
+				t.Errorf("GET %s = %d %q %q, want cached content", path, code, body, remaining)
+			}
+		}
+	}
+	if got := full.Load(); got != 2 {
+		t.Errorf("expected 2 full responses, got %d", got)
+	}
+	if got := notModified.Load(); got != 4 {
+		t.Errorf("expected 4 not modified responses, got %d", got)
+	}
+
+	// Another identity doesn't share cached responses.
+	get("/etag", "b")
+	if got := full.Load(); got != 3 {
+		t.Errorf("expected 3 full responses after request with other token, got %d", got)
+	}
+
+	// Responses without validators are not cached.
+	get("/plain", "a")
+	get("/plain", "a")
+	if got := full.Load(); got != 5 {
+		t.Errorf("expected 5 full responses, got %d", got)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected 3 cache files, got %d", len(entries))
+	}
+}
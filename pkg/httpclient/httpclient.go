@@ -2,8 +2,11 @@
 package httpclient
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
+	"errors"
+	"io"
 	"log"
 	"math"
 	"math/big"
@@ -12,6 +15,8 @@ import (
 	"time"
 
 	"github.com/google/go-github/v90/github"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
 )
 
 const (
@@ -23,6 +28,14 @@ const (
 
 	// DefaultMaxDelay caps the backoff delay.
 	DefaultMaxDelay = 30 * time.Second
+
+	// DefaultSecondaryRateLimitDelay is the initial delay after hitting a
+	// GitHub secondary rate limit without a Retry-After header.
+	DefaultSecondaryRateLimitDelay = 1 * time.Minute
+
+	// DefaultMaxRateLimitDelay caps the delay after hitting a rate limit.
+	// GitHub resets primary rate limits every hour.
+	DefaultMaxRateLimitDelay = 1 * time.Hour
 )
 
 // retryTransport is an http.RoundTripper that retries requests on transient failures.
@@ -31,6 +44,10 @@ type retryTransport struct {
 	maxRetries int
 	baseDelay  time.Duration
 	maxDelay   time.Duration
+
+	// Delays after exceeding GitHub rate limits.
+	secondaryRateLimitDelay time.Duration
+	maxRateLimitDelay       time.Duration
 }
 
 // RoundTrip executes the request with retry logic for transient failures.
@@ -49,9 +66,10 @@ func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 		resp, err = t.base.RoundTrip(req)
 
-		// On network error, retry.
+		// On network error, retry, unless replaying a recording, which
+		// would fail the same way again.
 		if err != nil {
-			if attempt < t.maxRetries {
+			if attempt < t.maxRetries && !errors.Is(err, errNotRecorded) {
 				delay := t.backoff(attempt, nil)
 				log.Printf("HTTP request to %q failed (attempt %d/%d): %v, retrying in %v", //nolint:gosec // G706: path is from our own request URL, not user input
 					req.URL.Path, attempt+1, t.maxRetries+1, err, delay)
@@ -62,7 +80,8 @@ func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 		}
 
 		// Check if the response status code is retryable.
-		if !isRetryableStatus(resp.StatusCode) {
+		rateLimited := isRateLimited(resp)
+		if !rateLimited && !isRetryableStatus(resp.StatusCode) {
 			return resp, nil
 		}
 
@@ -72,6 +91,9 @@ func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 		}
 
 		delay := t.backoff(attempt, resp)
+		if rateLimited {
+			delay = t.rateLimitDelay(attempt, resp)
+		}
 		log.Printf("HTTP %d from %s %q (attempt %d/%d), retrying in %v", //nolint:gosec // G706: path is from our own request URL, not user input
 			resp.StatusCode, req.Method, req.URL.Path, attempt+1, t.maxRetries+1, delay)
 
@@ -97,6 +119,56 @@ func isRetryableStatus(code int) bool {
 	return false
 }
 
+// isRateLimited returns true if the response signals that a GitHub rate
+// limit was exceeded: a primary rate limit via X-RateLimit-Remaining, or a
+// secondary rate limit via the error message.
+func isRateLimited(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return true
+	}
+
+	// Error bodies are small. Read a limited amount, and make it available
+	// again to the caller.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	if err != nil {
+		return false
+	}
+
+	return bytes.Contains(bytes.ToLower(body), []byte("secondary rate limit"))
+}
+
+// rateLimitDelay calculates the delay before retrying a request that
+// exceeded a GitHub rate limit, following GitHub's recommendations: wait as
+// long as the Retry-After header says, or until the rate limit is reset, or
+// at least one minute, increasing exponentially.
+func (t *retryTransport) rateLimitDelay(attempt int, resp *http.Response) time.Duration {
+	var delay time.Duration
+
+	retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+	reset, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	switch {
+	case retryAfter > 0:
+		delay = time.Duration(retryAfter) * time.Second
+	case resp.Header.Get("X-RateLimit-Remaining") == "0" && reset > 0:
+		delay = time.Until(time.Unix(reset, 0)) + time.Second
+		if delay <= 0 {
+			// The rate limit was reset in the meantime.
+			return t.backoff(attempt, nil)
+		}
+	default:
+		delay = t.secondaryRateLimitDelay * time.Duration(math.Pow(2, float64(attempt)))
+	}
+
+	return min(delay, t.maxRateLimitDelay)
+}
+
 // backoff calculates the delay before the next retry using exponential backoff with jitter.
 // If the response contains a Retry-After header, that value is used instead (capped at maxDelay).
 func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
@@ -135,13 +207,124 @@ func sleep(ctx context.Context, d time.Duration) {
 	}
 }
 
-// NewGitHubClient creates a new GitHub API client with retry logic.
-func NewGitHubClient(token string) (*github.Client, error) {
-	transport := &retryTransport{
-		base:       http.DefaultTransport,
-		maxRetries: DefaultMaxRetries,
-		baseDelay:  DefaultBaseDelay,
-		maxDelay:   DefaultMaxDelay,
+// Option is a functional option for configuring a GitHub client.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	requestInterval time.Duration
+	cache           bool
+	cacheDir        string
+	quota           *Quota
+	app             *AppCredentials
+	host            githubhost.Host
+}
+
+// WithRequestInterval limits the rate of requests, so that at most one
+// request is started per interval. This applies to all users of the client.
+func WithRequestInterval(interval time.Duration) Option {
+	return func(o *clientOptions) {
+		o.requestInterval = interval
+	}
+}
+
+// WithResponseCache keeps successful responses to GET requests in memory
+// for the lifetime of the client. Use this for clients shared between
+// several consumers that may request the same resources.
+func WithResponseCache() Option {
+	return func(o *clientOptions) {
+		o.cache = true
+	}
+}
+
+// WithDiskCache stores responses to GET requests in the given directory,
+// and revalidates them with conditional requests. This makes repeated runs
+// cheap, as unchanged resources don't count against the GitHub API rate
+// limit. The directory is created if needed.
+func WithDiskCache(dir string) Option {
+	return func(o *clientOptions) {
+		o.cacheDir = dir
+	}
+}
+
+// WithHost sends requests to the API of the given GitHub host, e.g. a
+// GitHub Enterprise Server, instead of api.github.com.
+func WithHost(host githubhost.Host) Option {
+	return func(o *clientOptions) {
+		o.host = host
+	}
+}
+
+// WithQuota tracks the rate limit quota in the given Quota, e.g. to log the
+// quota used at the end of a command. Requests are delayed if the quota is
+// low or exhausted, regardless of this option.
+func WithQuota(quota *Quota) Option {
+	return func(o *clientOptions) {
+		o.quota = quota
+	}
+}
+
+// NewGitHubClient creates a new GitHub API client with retry logic. The
+// token is ignored if GitHub App credentials are given via
+// WithAppCredentials.
+func NewGitHubClient(token string, options ...Option) (*github.Client, error) {
+	var o clientOptions
+	for _, apply := range options {
+		apply(&o)
+	}
+
+	// Rate limiting and quota tracking apply to each attempt, including
+	// retries.
+	base := http.DefaultTransport
+	if o.requestInterval > 0 {
+		base = &rateLimitTransport{
+			base:     base,
+			interval: o.requestInterval,
+		}
+	}
+
+	quota := o.quota
+	if quota == nil {
+		quota = NewQuota()
+	}
+	base = &quotaTransport{
+		base:  base,
+		quota: quota,
+	}
+
+	var transport http.RoundTripper = &retryTransport{
+		base:                    base,
+		maxRetries:              DefaultMaxRetries,
+		baseDelay:               DefaultBaseDelay,
+		maxDelay:                DefaultMaxDelay,
+		secondaryRateLimitDelay: DefaultSecondaryRateLimitDelay,
+		maxRateLimitDelay:       DefaultMaxRateLimitDelay,
+	}
+
+	// The disk cache must see the identity, but not the installation
+	// tokens of an app, which change every hour.
+	identity := ""
+	if o.app != nil {
+		app, err := newAppTransport(transport, *o.app, o.host.APIURL())
+		if err != nil {
+			return nil, err
+		}
+		transport = app
+		identity = app.identity()
+	}
+
+	if o.cacheDir != "" {
+		transport = &diskCacheTransport{
+			base:     transport,
+			dir:      o.cacheDir,
+			identity: identity,
+		}
+	}
+
+	if o.cache {
+		transport = &cacheTransport{
+			base:      transport,
+			responses: make(map[string][]byte),
+		}
 	}
 
 	httpClient := &http.Client{
@@ -149,9 +332,12 @@ func NewGitHubClient(token string) (*github.Client, error) {
 	}
 
 	opts := []github.ClientOptionsFunc{github.WithHTTPClient(httpClient)}
-	if token != "" {
+	if token != "" && o.app == nil {
 		opts = append(opts, github.WithAuthToken(token))
 	}
+	if o.host.IsEnterprise() {
+		opts = append(opts, github.WithEnterpriseURLs(o.host.APIURL(), o.host.UploadURL()))
+	}
 
 	return github.NewClient(opts...)
 }
@@ -7,6 +7,8 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
 )
 
 func TestRetryTransport_Success(t *testing.T) {
@@ -297,3 +299,20 @@ func TestNewGitHubClient_NoToken(t *testing.T) {
 		t.Fatal("NewGitHubClient with empty token returned nil")
 	}
 }
+
+func TestNewGitHubClient_EnterpriseHost(t *testing.T) {
+	host, err := githubhost.New("github.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewGitHubClient("test-token", WithHost(host))
+	if err != nil {
+		t.Fatalf("NewGitHubClient returned error: %v", err)
+	}
+	if got := client.BaseURL(); got != "https://github.example.com/api/v3/" {
+		t.Errorf("BaseURL = %q, want %q", got, "https://github.example.com/api/v3/")
+	}
+	if got := client.UploadURL(); got != "https://github.example.com/api/uploads/" {
+		t.Errorf("UploadURL = %q, want %q", got, "https://github.example.com/api/uploads/")
+	}
+}
@@ -0,0 +1,191 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fraction of the rate limit below which requests are slowed down, so that
+// the remaining quota is spread until the reset.
+const lowQuotaFraction = 0.05
+
+// Quota tracks the GitHub API rate limit quota reported in responses, per
+// rate limit resource like "core" or "graphql". It is safe for concurrent
+// use.
+type Quota struct {
+	mu        sync.Mutex
+	resources map[string]*resourceQuota
+}
+
+// resourceQuota is the state of the rate limit of one resource.
+type resourceQuota struct {
+	limit     int
+	remaining int
+	reset     time.Time
+
+	// Requests counted against the rate limit since tracking started.
+	used int
+}
+
+// NewQuota returns a new, empty quota tracker.
+func NewQuota() *Quota {
+	return &Quota{resources: make(map[string]*resourceQuota)}
+}
+
+// String describes the quota used per resource, e.g. for logging at the
+// end of a command.
+func (q *Quota) String() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.resources) == 0 {
+		return "no rate-limited requests"
+	}
+
+	var parts []string
+	for _, name := range slices.Sorted(maps.Keys(q.resources)) {
+		r := q.resources[name]
+		parts = append(parts, fmt.Sprintf("%s: %d used, %d of %d remaining until %s", name, r.used, r.remaining, r.limit, r.reset.Format(time.TimeOnly)))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// update records the rate limit headers of a response, if present, and
+// returns the resource and whether its quota is exhausted now.
+func (q *Quota) update(resp *http.Response) (string, bool) {
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	limit, err1 := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	remaining, err2 := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	reset, err3 := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return "", false
+	}
+	if resource == "" {
+		resource = resourceFor(resp.Request)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	r, ok := q.resources[resource]
+	if !ok {
+		r = &resourceQuota{}
+		q.resources[resource] = r
+	}
+
+	resetTime := time.Unix(reset, 0)
+	switch {
+	case !resetTime.Equal(r.reset):
+		// First response, or a new rate limit window.
+		if resp.StatusCode != http.StatusNotModified {
+			r.used++
+		}
+		r.remaining = remaining
+	case remaining < r.remaining:
+		// Responses to concurrent requests may arrive out of order.
+		r.used += r.remaining - remaining
+		r.remaining = remaining
+	}
+	r.limit = limit
+	r.reset = resetTime
+
+	return resource, r.remaining <= 0
+}
+
+// wait returns how long to wait before the next request to the given
+// resource, to not exceed the rate limit.
+func (q *Quota) wait(resource string, now time.Time) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	r, ok := q.resources[resource]
+	if !ok || !now.Before(r.reset) {
+		return 0
+	}
+
+	untilReset := r.reset.Sub(now) + time.Second
+	if r.remaining <= 0 {
+		return untilReset
+	}
+	if float64(r.remaining) < lowQuotaFraction*float64(r.limit) {
+		// Spread the remaining requests until the reset.
+		return untilReset / time.Duration(r.remaining+1)
+	}
+	return 0
+}
+
+// resourceFor returns the name of the rate limit resource a request to the
+// GitHub API counts against.
+func resourceFor(req *http.Request) string {
+	if req == nil {
+		return "core"
+	}
+	switch path := req.URL.Path; {
+	case strings.HasSuffix(path, "/graphql"):
+		return "graphql"
+	case strings.Contains(path, "/search/"):
+		return "search"
+	default:
+		return "core"
+	}
+}
+
+// quotaTransport is an http.RoundTripper that tracks the rate limit quota
+// in responses, and delays requests if the quota is low or exhausted, until
+// it is reset.
+type quotaTransport struct {
+	base  http.RoundTripper
+	quota *Quota
+
+	// Function to wait with, defaults to sleep. Replaced in tests.
+	sleep func(context.Context, time.Duration)
+}
+
+// RoundTrip waits if required by the quota, then executes the request.
+func (t *quotaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.pause(req.Context(), resourceFor(req)); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// If the quota is exhausted now, pause before returning, as the GitHub
+	// client refuses to send further requests until the reset.
+	if resource, exhausted := t.quota.update(resp); exhausted {
+		if err := t.pause(req.Context(), resource); err != nil {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// pause waits as long as required by the quota of the resource.
+func (t *quotaTransport) pause(ctx context.Context, resource string) error {
+	wait := t.quota.wait(resource, time.Now())
+	if wait <= 0 {
+		return nil
+	}
+
+	if wait > time.Second {
+		log.Printf("GitHub API rate limit for %s nearly exhausted, pausing for %v", resource, wait.Round(time.Second))
+	}
+	sleepFunc := t.sleep
+	if sleepFunc == nil {
+		sleepFunc = sleep
+	}
+	sleepFunc(ctx, wait)
+	return ctx.Err()
+}
@@ -0,0 +1,202 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func rateLimitResponse(status int, resource string, limit, remaining int, reset time.Time) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Resource", resource)
+	resp.Header.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+	resp.Header.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	return resp
+}
+
+func TestQuota(t *testing.T) {
+	now := time.Now()
+	reset := now.Add(10 * time.Minute).Truncate(time.Second)
+	q := NewQuota()
+
+	if got := q.String(); got != "no rate-limited requests" {
+		t.Errorf("String() = %q for empty quota", got)
+	}
+
+	q.update(rateLimitResponse(http.StatusOK, "core", 5000, 4990, reset))
+	q.update(rateLimitResponse(http.StatusOK, "core", 5000, 4980, reset))
+	// Out of order
+	q.update(rateLimitResponse(http.StatusOK, "core", 5000, 4985, reset))
+	// Not counted against the rate limit.
+	q.update(rateLimitResponse(http.StatusNotModified, "graphql", 5000, 5000, reset))
+
+	if got := q.resources["core"].used; got != 11 {
+		t.Errorf("core used = %d, want 11", got)
+	}
+	if got := q.resources["graphql"].used; got != 0 {
+		t.Errorf("graphql used = %d, want 0", got)
+	}
+	if got := q.wait("core", now); got != 0 {
+		t.Errorf("wait() with plenty of quota = %v, want 0", got)
+	}
+
+	// Low quota: spread the remaining requests until reset.
+	q.update(rateLimitResponse(http.StatusOK, "core", 5000, 99, reset))
+	if got, max := q.wait("core", now), reset.Sub(now)/50; got <= 0 || got > max {
+		t.Errorf("wait() with low quota = %v, want up to %v", got, max)
+	}
+
+	// Exhausted: wait until reset.
+	_, exhausted := q.update(rateLimitResponse(http.StatusForbidden, "core", 5000, 0, reset))
+	if !exhausted {
+		t.Error("update() did not report exhausted quota")
+	}
+	if got, want := q.wait("core", now), reset.Sub(now)+time.Second; got != want {
+		t.Errorf("wait() with exhausted quota = %v, want %v", got, want)
+	}
+	if got := q.wait("core", reset.Add(time.Second)); got != 0 {
+		t.Errorf("wait() after reset = %v, want 0", got)
+	}
+
+	// A new window
+	q.update(rateLimitResponse(http.StatusOK, "core", 5000, 4999, reset.Add(time.Hour)))
+	if got := q.resources["core"].used; got != 4992 {
+		t.Errorf("core used = %d, want 4992", got)
+	}
+}
+
+func TestQuotaTransport(t *testing.T) {
+	reset := time.Now().Add(time.Hour)
+	var remaining atomic.Int32
+	remaining.Store(2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := remaining.Add(-1)
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(n)))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var pauses []time.Duration
+	quota := NewQuota()
+	client := &http.Client{Transport: &quotaTransport{
+		base:  http.DefaultTransport,
+		quota: quota,
+		sleep: func(ctx context.Context, d time.Duration) { pauses = append(pauses, d) },
+	}}
+
+	for range 2 {
+		resp, err := client.Get(server.URL + "/repos/giantswarm/a")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	// The first request is slowed down, the second one exhausts the quota.
+	if len(pauses) != 2 || pauses[0] <= 0 || pauses[1] < 59*time.Minute {
+		t.Errorf("pauses = %v, want a short and a long one", pauses)
+	}
+	if got := quota.resources["core"].used; got != 2 {
+		t.Errorf("core used = %d, want 2", got)
+	}
+}
+
+func TestRetryTransport_RateLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      map[string]string
+		body        string
+		wantRetried bool
+	}{
+		{
+			name:        "Secondary",
+			header:      map[string]string{"Retry-After": "1"},
+			body:        `{"message": "You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`,
+			wantRetried: true,
+		},
+		{
+			name:        "PrimaryAlreadyReset",
+			header:      map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10)},
+			body:        `{"message": "API rate limit exceeded"}`,
+			wantRetried: true,
+		},
+		{
+			name: "Forbidden",
+			body: `{"message": "Resource not accessible by integration"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) > 1 {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				for k, v := range tt.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: &retryTransport{
+				base:                    http.DefaultTransport,
+				maxRetries:              3,
+				baseDelay:               10 * time.Millisecond,
+				maxDelay:                100 * time.Millisecond,
+				secondaryRateLimitDelay: 10 * time.Millisecond,
+				maxRateLimitDelay:       time.Minute,
+			}}
+
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+
+			if retried := attempts.Load() > 1; retried != tt.wantRetried {
+				t.Errorf("retried = %v, want %v", retried, tt.wantRetried)
+			}
+			if !tt.wantRetried {
+				body, _ := io.ReadAll(resp.Body)
+				if string(body) != tt.body {
+					t.Errorf("body = %q, want %q", body, tt.body)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitDelay(t *testing.T) {
+	transport := &retryTransport{
+		secondaryRateLimitDelay: time.Minute,
+		maxRateLimitDelay:       time.Hour,
+	}
+
+	resp := &http.Response{Header: http.Header{}}
+	if got := transport.rateLimitDelay(2, resp); got != 4*time.Minute {
+		t.Errorf("secondary rate limit delay = %v, want 4m", got)
+	}
+
+	resp.Header.Set("Retry-After", "7200")
+	if got := transport.rateLimitDelay(0, resp); got != time.Hour {
+		t.Errorf("delay with Retry-After = %v, want capped at 1h", got)
+	}
+
+	resp.Header.Del("Retry-After")
+	resp.Header.Set("X-RateLimit-Remaining", "0")
+	resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(10*time.Minute).Unix(), 10))
+	if got := transport.rateLimitDelay(0, resp); got < 9*time.Minute || got > 11*time.Minute {
+		t.Errorf("primary rate limit delay = %v, want about 10m", got)
+	}
+}
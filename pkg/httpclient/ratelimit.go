@@ -0,0 +1,39 @@
+package httpclient
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimitTransport is an http.RoundTripper that spaces out requests, so
+// that at most one request is started per interval, across all goroutines
+// sharing the transport.
+type rateLimitTransport struct {
+	base     http.RoundTripper
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// RoundTrip waits for the next free slot, then executes the request.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	now := time.Now()
+	start := now
+	if t.next.After(now) {
+		start = t.next
+	}
+	t.next = start.Add(t.interval)
+	t.mu.Unlock()
+
+	if wait := start.Sub(now); wait > 0 {
+		sleep(req.Context(), wait)
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,41 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimitTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	interval := 20 * time.Millisecond
+	client := &http.Client{Transport: &rateLimitTransport{
+		base:     http.DefaultTransport,
+		interval: interval,
+	}}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			_ = resp.Body.Close()
+		})
+	}
+	wg.Wait()
+
+	// The first request starts immediately, the others wait for their slot.
+	if elapsed := time.Since(start); elapsed < 4*interval {
+		t.Errorf("5 requests took %v, expected at least %v", elapsed, 4*interval)
+	}
+}
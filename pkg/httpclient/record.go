@@ -0,0 +1,397 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// Value replacing secrets in recorded interactions.
+const redacted = "REDACTED"
+
+// Headers never written to recordings, as they hold credentials or would
+// not match recorded bodies.
+var scrubbedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"Set-Cookie",
+	"Content-Length",
+}
+
+// Keys of JSON objects and form fields whose values are redacted in
+// recordings, e.g. access tokens and client secrets.
+var scrubbedFields = []string{
+	"access_token",
+	"client_id",
+	"client_secret",
+	"password",
+	"refresh_token",
+	"token",
+}
+
+// errNotRecorded is returned on replay for requests without recorded
+// interaction. Such requests are not retried.
+var errNotRecorded = errors.New("no recorded response")
+
+// Characters not used in recording file names.
+var fileNameReplacer = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// interaction is a recorded HTTP request with its response, stored as one
+// JSON file per interaction.
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	recordedBody
+}
+
+type recordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	recordedBody
+}
+
+// recordedBody holds a body as JSON document, as text, or base64 encoded,
+// whichever fits first.
+type recordedBody struct {
+	JSON       json.RawMessage `json:"json,omitempty"`
+	Body       string          `json:"body,omitempty"`
+	BodyBase64 []byte          `json:"bodyBase64,omitempty"`
+}
+
+func newRecordedBody(data []byte) recordedBody {
+	switch {
+	case len(data) == 0:
+		return recordedBody{}
+	case json.Valid(data):
+		return recordedBody{JSON: data}
+	case utf8.Valid(data):
+		return recordedBody{Body: string(data)}
+	default:
+		return recordedBody{BodyBase64: data}
+	}
+}
+
+func (b recordedBody) bytes() []byte {
+	switch {
+	case len(b.JSON) > 0:
+		return b.JSON
+	case b.Body != "":
+		return []byte(b.Body)
+	default:
+		return b.BodyBase64
+	}
+}
+
+// key identifies requests to be answered by the same recorded response.
+// JSON bodies are compared regardless of formatting.
+func (r recordedRequest) key() string {
+	body := r.bytes()
+	var compact bytes.Buffer
+	if json.Compact(&compact, body) == nil {
+		body = compact.Bytes()
+	}
+	return r.Method + " " + r.URL + "\n" + string(body)
+}
+
+// Record records all HTTP interactions made via http.DefaultTransport to
+// files in dir, which must be empty or not exist yet. Credentials are
+// scrubbed from the recordings. This covers the GitHub clients, as well as
+// the Personio and OCI registry clients, as long as clients are created after
+// calling Record. Returns a function restoring the previous transport.
+func Record(dir string) (restore func(), err error) {
+	err = os.MkdirAll(dir, 0750)
+	if err != nil {
+		return nil, fmt.Errorf("could not create recording directory: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read recording directory: %w", err)
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("recording directory %s is not empty", dir)
+	}
+
+	return setDefaultTransport(&recordTransport{base: http.DefaultTransport, dir: dir}), nil
+}
+
+// Replay answers all HTTP requests made via http.DefaultTransport with the
+// interactions recorded in dir by Record, without any network access.
+// Requests are matched by method, URL and body. Requests made repeatedly
+// are answered with the recorded responses in order, and with the last one
+// once these are used up. Returns a function restoring the previous
+// transport.
+func Replay(dir string) (restore func(), err error) {
+	t, err := newReplayTransport(dir)
+	if err != nil {
+		return nil, err
+	}
+	return setDefaultTransport(t), nil
+}
+
+func setDefaultTransport(t http.RoundTripper) (restore func()) {
+	previous := http.DefaultTransport
+	http.DefaultTransport = t
+	return func() {
+		http.DefaultTransport = previous
+	}
+}
+
+// recordTransport is an http.RoundTripper writing every interaction to a
+// file in dir.
+type recordTransport struct {
+	base http.RoundTripper
+	dir  string
+	seq  atomic.Int64
+}
+
+// RoundTrip passes the request on and records it with the response.
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	recorded := interaction{
+		Request:  scrubRequest(req, reqBody),
+		Response: scrubResponse(resp, respBody),
+	}
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%04d-%s-%s", t.seq.Add(1), req.Method, req.URL.Host+req.URL.Path)
+	name = fileNameReplacer.ReplaceAllString(name, "_")
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	err = os.WriteFile(filepath.Join(t.dir, name+".json"), append(data, '\n'), 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not record interaction: %w", err)
+	}
+
+	return resp, nil
+}
+
+// replayTransport is an http.RoundTripper answering requests with recorded
+// interactions.
+type replayTransport struct {
+	mu           sync.Mutex
+	interactions map[string][]recordedResponse
+	used         map[string]int
+}
+
+func newReplayTransport(dir string) (*replayTransport, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recorded interactions found in %s", dir)
+	}
+	slices.Sort(files)
+
+	t := &replayTransport{
+		interactions: make(map[string][]recordedResponse),
+		used:         make(map[string]int),
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return nil, err
+		}
+		var i interaction
+		err = json.Unmarshal(data, &i)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse recorded interaction %s: %w", file, err)
+		}
+		key := i.Request.key()
+		t.interactions[key] = append(t.interactions[key], i.Response)
+	}
+
+	return t, nil
+}
+
+// RoundTrip returns the recorded response for the request.
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	key := scrubRequest(req, reqBody).key()
+
+	t.mu.Lock()
+	responses := t.interactions[key]
+	n := t.used[key]
+	t.used[key]++
+	t.mu.Unlock()
+
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("%w for %s %s", errNotRecorded, req.Method, req.URL)
+	}
+	recorded := responses[min(n, len(responses)-1)]
+
+	body := recorded.bytes()
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// scrubRequest returns the request as recorded, without credentials.
+func scrubRequest(req *http.Request, body []byte) recordedRequest {
+	u := *req.URL
+	query := u.Query()
+	scrubbed := false
+	for key := range query {
+		if slices.Contains(scrubbedFields, strings.ToLower(key)) {
+			query.Set(key, redacted)
+			scrubbed = true
+		}
+	}
+	if scrubbed {
+		u.RawQuery = query.Encode()
+	}
+
+	return recordedRequest{
+		Method:       req.Method,
+		URL:          u.String(),
+		Header:       scrubHeader(req.Header),
+		recordedBody: newRecordedBody(scrubBody(req.Header.Get("Content-Type"), body)),
+	}
+}
+
+// scrubResponse returns the response as recorded, without credentials. A
+// new token passed in the Authorization header, as done by Personio, is
+// replaced, so that clients keep using it on replay.
+func scrubResponse(resp *http.Response, body []byte) recordedResponse {
+	header := scrubHeader(resp.Header)
+	if resp.Header.Get("Authorization") != "" {
+		if header == nil {
+			header = http.Header{}
+		}
+		header.Set("Authorization", "Bearer "+redacted)
+	}
+
+	return recordedResponse{
+		Status:       resp.StatusCode,
+		Header:       header,
+		recordedBody: newRecordedBody(scrubBody(resp.Header.Get("Content-Type"), body)),
+	}
+}
+
+func scrubHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range scrubbedHeaders {
+		header.Del(name)
+	}
+	if len(header) == 0 {
+		return nil
+	}
+	return header
+}
+
+// scrubBody redacts credentials in JSON and form encoded bodies.
+func scrubBody(contentType string, body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+		for key := range form {
+			if slices.Contains(scrubbedFields, strings.ToLower(key)) {
+				form.Set(key, redacted)
+			}
+		}
+		return []byte(form.Encode())
+	}
+
+	var doc any
+	if json.Unmarshal(body, &doc) != nil {
+		return body
+	}
+	if !scrubJSON(doc) {
+		return body
+	}
+	scrubbed, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return scrubbed
+}
+
+// scrubJSON redacts credentials in a decoded JSON document in place, and
+// returns whether anything was redacted.
+func scrubJSON(doc any) bool {
+	changed := false
+	switch v := doc.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if _, ok := value.(string); ok && slices.Contains(scrubbedFields, strings.ToLower(key)) {
+				v[key] = redacted
+				changed = true
+				continue
+			}
+			changed = scrubJSON(value) || changed
+		}
+	case []any:
+		for _, value := range v {
+			changed = scrubJSON(value) || changed
+		}
+	}
+	return changed
+}
@@ -0,0 +1,188 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRecordReplay(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		switch r.URL.Path {
+		case "/auth":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"success":true,"data":{"token":"secret-token"}}`))
+		default:
+			w.Header().Set("Authorization", "Bearer rotated-token")
+			_, _ = fmt.Fprintf(w, "call %d", n)
+		}
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "recording")
+
+	// Requests made while recording, with the responses expected on replay.
+	requests := func(t *testing.T) []string {
+		t.Helper()
+		client := &http.Client{}
+		var bodies []string
+
+		form := url.Values{"client_id": {"id"}, "client_secret": {"secret"}}
+		resp, err := client.PostForm(server.URL+"/auth", form)
+		if err != nil {
+			t.Fatalf("POST unexpected error: %v", err)
+		}
+		bodies = append(bodies, readBody(t, resp))
+
+		for range 3 {
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer secret-token")
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("GET unexpected error: %v", err)
+			}
+			bodies = append(bodies, readBody(t, resp))
+		}
+		return bodies
+	}
+
+	restore, err := Record(dir)
+	if err != nil {
+		t.Fatalf("Record() unexpected error: %v", err)
+	}
+	recorded := requests(t)
+	restore()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 4 {
+		t.Fatalf("recorded %d interactions, want 4", len(files))
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{"secret-token", "rotated-token", "client_secret=secret", "client_id=id"} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("recording %s contains %q", filepath.Base(file), secret)
+			}
+		}
+	}
+
+	if _, err := Record(dir); err == nil {
+		t.Errorf("Record() expected error for non-empty directory")
+	}
+
+	restore, err = Replay(dir)
+	if err != nil {
+		t.Fatalf("Replay() unexpected error: %v", err)
+	}
+	defer restore()
+	server.Close()
+
+	// Recorded JSON bodies are reformatted.
+	replayed := requests(t)
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(replayed[0])); err != nil {
+		t.Fatalf("replayed invalid JSON: %v", err)
+	}
+	replayed[0] = compact.String()
+	want := []string{`{"data":{"token":"REDACTED"},"success":true}`, recorded[1], recorded[2], recorded[3]}
+	for i := range want {
+		if replayed[i] != want[i] {
+			t.Errorf("replayed response %d = %q, want %q", i, replayed[i], want[i])
+		}
+	}
+
+	// Responses are used up, so the last one is repeated.
+	resp, err := http.Get(server.URL + "/items")
+	if err != nil {
+		t.Fatalf("GET unexpected error: %v", err)
+	}
+	if got := readBody(t, resp); got != recorded[3] {
+		t.Errorf("repeated response = %q, want %q", got, recorded[3])
+	}
+
+	if _, err := http.Get(server.URL + "/unknown"); err == nil {
+		t.Errorf("GET expected error for request not recorded")
+	}
+}
+
+func TestReplayEmptyDirectory(t *testing.T) {
+	if _, err := Replay(t.TempDir()); err == nil {
+		t.Errorf("Replay() expected error for directory without recordings")
+	}
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestReplayMissNotRetried(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "0001-GET-example.com_items.json"), []byte(`{
+  "request": {"method": "GET", "url": "https://example.com/items"},
+  "response": {"status": 200, "body": "items"}
+}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay, err := newReplayTransport(dir)
+	if err != nil {
+		t.Fatalf("newReplayTransport() unexpected error: %v", err)
+	}
+
+	var calls atomic.Int32
+	transport := &retryTransport{
+		base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			return replay.RoundTrip(req)
+		}),
+		maxRetries: 3,
+		baseDelay:  time.Hour,
+		maxDelay:   time.Hour,
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/unknown", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = transport.RoundTrip(req)
+	if !errors.Is(err, errNotRecorded) {
+		t.Errorf("RoundTrip() error = %v, want %v", err, errNotRecorded)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("RoundTrip() made %d attempts, want 1", calls.Load())
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,55 @@
+// Package cataloginfo provides functionality to parse hand-maintained
+// catalog-info.yaml files found in component repositories.
+package cataloginfo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/giantswarm/microerror"
+	"go.yaml.in/yaml/v3"
+
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+)
+
+// Component is a component entity descriptor as found in a catalog-info file.
+type Component struct {
+	APIVersion string                   `yaml:"apiVersion"`
+	Kind       string                   `yaml:"kind"`
+	Metadata   bscatalog.EntityMetadata `yaml:"metadata"`
+	Spec       bscatalog.ComponentSpec  `yaml:"spec"`
+}
+
+// FindComponent parses the given catalog-info file content, which may
+// contain several YAML documents, and returns the descriptor of the
+// component with the given name. If the file contains only one component,
+// it is returned regardless of its name.
+func FindComponent(data []byte, name string) (*Component, error) {
+	var components []*Component
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc Component
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, microerror.Maskf(parseError, "%s", err)
+		}
+
+		if doc.Kind != string(bscatalog.EntityKindComponent) {
+			continue
+		}
+		if doc.Metadata.Name == name {
+			return &doc, nil
+		}
+		components = append(components, &doc)
+	}
+
+	if len(components) == 1 {
+		return components[0], nil
+	}
+
+	return nil, microerror.Maskf(componentNotFoundError, "no component named %q found", name)
+}
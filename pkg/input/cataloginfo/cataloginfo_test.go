@@ -0,0 +1,110 @@
+package cataloginfo
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+)
+
+func TestFindComponent(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		componentName string
+		want          *Component
+		errorMatcher  func(error) bool
+	}{
+		{
+			name: "single component with other name",
+			data: `apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: other-name
+  annotations:
+    example.com/key: value
+spec:
+  providesApis:
+  - some-api
+`,
+			componentName: "my-service",
+			want: &Component{
+				APIVersion: "backstage.io/v1alpha1",
+				Kind:       "Component",
+				Metadata: bscatalog.EntityMetadata{
+					Name:        "other-name",
+					Annotations: map[string]string{"example.com/key": "value"},
+				},
+				Spec: bscatalog.ComponentSpec{
+					ProvidesAPIs: []string{"some-api"},
+				},
+			},
+		},
+		{
+			name: "several documents",
+			data: `apiVersion: backstage.io/v1alpha1
+kind: API
+metadata:
+  name: my-service
+---
+apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: other-component
+---
+apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: my-service
+  title: My service
+`,
+			componentName: "my-service",
+			want: &Component{
+				APIVersion: "backstage.io/v1alpha1",
+				Kind:       "Component",
+				Metadata: bscatalog.EntityMetadata{
+					Name:  "my-service",
+					Title: "My service",
+				},
+			},
+		},
+		{
+			name: "several components, none matching",
+			data: `kind: Component
+metadata:
+  name: a
+---
+kind: Component
+metadata:
+  name: b
+`,
+			componentName: "my-service",
+			errorMatcher:  IsComponentNotFoundError,
+		},
+		{
+			name:          "invalid YAML",
+			data:          "kind: [",
+			componentName: "my-service",
+			errorMatcher:  func(err error) bool { return err != nil },
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := FindComponent([]byte(tc.data), tc.componentName)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("FindComponent() unexpected error %v", err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("FindComponent() unexpected error %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("FindComponent() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package cataloginfo
+
+import "github.com/giantswarm/microerror"
+
+var parseError = &microerror.Error{
+	Kind: "parseError",
+}
+
+var componentNotFoundError = &microerror.Error{
+	Kind: "componentNotFoundError",
+}
+
+// IsComponentNotFoundError returns true if error is componentNotFoundError.
+func IsComponentNotFoundError(err error) bool {
+	return microerror.Cause(err) == componentNotFoundError
+}
@@ -0,0 +1,69 @@
+// Package codeowners provides functionality to parse GitHub CODEOWNERS files.
+//
+// See https://docs.github.com/en/repositories/managing-your-repositorys-settings-and-features/customizing-your-repository/about-code-owners
+package codeowners
+
+import (
+	"slices"
+	"strings"
+)
+
+// Rule is one line of a CODEOWNERS file, assigning owners to the files
+// matching a pattern.
+type Rule struct {
+	// Pattern is the gitignore-style file pattern.
+	Pattern string
+
+	// Owners are user names (@user), team names (@org/team), or email
+	// addresses, as given in the file.
+	Owners []string
+}
+
+// Parse returns the rules of a CODEOWNERS file, in the order of appearance.
+// Comments and empty lines are skipped.
+func Parse(content string) []Rule {
+	var rules []Rule
+
+	for line := range strings.Lines(content) {
+		// Escaped "#" may occur in patterns only.
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		rules = append(rules, Rule{
+			Pattern: fields[0],
+			Owners:  fields[1:],
+		})
+	}
+
+	return rules
+}
+
+// Teams returns the sorted, distinct slugs of the teams of the given GitHub
+// organization that own any files according to the CODEOWNERS content.
+func Teams(content, organization string) []string {
+	prefix := "@" + strings.ToLower(organization) + "/"
+
+	var teams []string
+	for _, rule := range Parse(content) {
+		for _, owner := range rule.Owners {
+			slug, found := strings.CutPrefix(strings.ToLower(owner), prefix)
+			if !found || slug == "" || slices.Contains(teams, slug) {
+				continue
+			}
+			teams = append(teams, slug)
+		}
+	}
+
+	slices.Sort(teams)
+
+	return teams
+}
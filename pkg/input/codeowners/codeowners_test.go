@@ -0,0 +1,44 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testContent = `# Default owners
+* @giantswarm/team-honeybadger
+
+# Helm chart
+/helm/ @giantswarm/Team-Atlas @some-user # inline comment
+docs/* docs@example.com @giantswarm/team-honeybadger
+
+/vendor/
+/external/ @other-org/team-x
+`
+
+func TestParse(t *testing.T) {
+	want := []Rule{
+		{Pattern: "*", Owners: []string{"@giantswarm/team-honeybadger"}},
+		{Pattern: "/helm/", Owners: []string{"@giantswarm/Team-Atlas", "@some-user"}},
+		{Pattern: "docs/*", Owners: []string{"docs@example.com", "@giantswarm/team-honeybadger"}},
+		{Pattern: "/vendor/", Owners: []string{}},
+		{Pattern: "/external/", Owners: []string{"@other-org/team-x"}},
+	}
+
+	if diff := cmp.Diff(want, Parse(testContent)); diff != "" {
+		t.Errorf("Parse() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTeams(t *testing.T) {
+	want := []string{"team-atlas", "team-honeybadger"}
+
+	if diff := cmp.Diff(want, Teams(testContent, "giantswarm")); diff != "" {
+		t.Errorf("Teams() mismatch (-want +got):\n%s", diff)
+	}
+
+	if got := Teams("", "giantswarm"); got != nil {
+		t.Errorf("Teams() = %v, want nil", got)
+	}
+}
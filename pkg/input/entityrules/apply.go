@@ -0,0 +1,143 @@
+package entityrules
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/giantswarm/microerror"
+
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+)
+
+// Rules is a compiled set of rules, ready to be applied to entities.
+type Rules struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	name         string
+	match        compiledMatch
+	exclude      compiledMatch
+	excludeEmpty bool
+	links        []compiledLink
+	annotations  map[string]*template.Template
+}
+
+type compiledMatch struct {
+	kinds  []string
+	types  []string
+	tags   []string
+	labels map[string]*regexp.Regexp
+}
+
+type compiledLink struct {
+	url      *template.Template
+	title    *template.Template
+	icon     string
+	linkType string
+}
+
+// Apply adds the links and annotations of all rules matching the entity,
+// in the order of the rules. Annotations already set on the entity are not
+// overwritten, and links with a URL already present are not added again.
+// Links with an empty URL and annotations with an empty value are skipped.
+func (r *Rules) Apply(e *bscatalog.Entity) error {
+	if r == nil {
+		return nil
+	}
+
+	for _, rule := range r.rules {
+		if !rule.match.matches(e) || (!rule.excludeEmpty && rule.exclude.matches(e)) {
+			continue
+		}
+
+		for _, l := range rule.links {
+			url, err := render(l.url, e)
+			if err != nil {
+				return microerror.Maskf(templateError, "rule %q: link url: %v", rule.name, err)
+			}
+			if url == "" || slices.ContainsFunc(e.Metadata.Links, func(existing bscatalog.EntityLink) bool { return existing.URL == url }) {
+				continue
+			}
+			title, err := render(l.title, e)
+			if err != nil {
+				return microerror.Maskf(templateError, "rule %q: link title: %v", rule.name, err)
+			}
+			e.Metadata.Links = append(e.Metadata.Links, bscatalog.EntityLink{
+				URL:   url,
+				Title: title,
+				Icon:  l.icon,
+				Type:  l.linkType,
+			})
+		}
+
+		for key, t := range rule.annotations {
+			if _, exists := e.Metadata.Annotations[key]; exists {
+				continue
+			}
+			value, err := render(t, e)
+			if err != nil {
+				return microerror.Maskf(templateError, "rule %q: annotation %s: %v", rule.name, key, err)
+			}
+			if value == "" {
+				continue
+			}
+			if e.Metadata.Annotations == nil {
+				e.Metadata.Annotations = make(map[string]string)
+			}
+			e.Metadata.Annotations[key] = value
+		}
+	}
+
+	return nil
+}
+
+func (m compiledMatch) matches(e *bscatalog.Entity) bool {
+	if len(m.kinds) > 0 && !slices.ContainsFunc(m.kinds, func(k string) bool { return strings.EqualFold(k, string(e.Kind)) }) {
+		return false
+	}
+	if len(m.types) > 0 && !slices.Contains(m.types, entityType(e)) {
+		return false
+	}
+	for _, tag := range m.tags {
+		if !slices.Contains(e.Metadata.Tags, tag) {
+			return false
+		}
+	}
+	for label, re := range m.labels {
+		if !re.MatchString(e.Metadata.Labels[label]) {
+			return false
+		}
+	}
+	return true
+}
+
+// entityType returns the spec.type of the entity, if its kind has one.
+func entityType(e *bscatalog.Entity) string {
+	switch spec := e.Spec.(type) {
+	case bscatalog.ComponentSpec:
+		return spec.Type
+	case *bscatalog.ComponentSpec:
+		return spec.Type
+	case bscatalog.ResourceSpec:
+		return spec.Type
+	case *bscatalog.ResourceSpec:
+		return spec.Type
+	case bscatalog.APISpec:
+		return spec.Type
+	case *bscatalog.APISpec:
+		return spec.Type
+	}
+	return ""
+}
+
+func render(t *template.Template, e *bscatalog.Entity) (string, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, e); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,258 @@
+// Package entityrules provides functionality to parse and apply declarative
+// rules adding links and annotations to catalog entities.
+//
+// A rule matches entities by kind, type, tags, and labels. Link URLs and
+// titles as well as annotation values are Go templates, executed with the
+// entity as data.
+package entityrules
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/giantswarm/microerror"
+	"go.yaml.in/yaml/v3"
+)
+
+//go:embed defaults.yaml
+var defaultRules []byte
+
+// Rule adds links and annotations to the entities it matches.
+type Rule struct {
+	// Name identifies the rule in error messages (required).
+	Name string `yaml:"name"`
+
+	// Match selects the entities the rule applies to.
+	Match Match `yaml:"match"`
+
+	// Exclude deselects entities selected via Match. Ignored if empty.
+	Exclude Match `yaml:"exclude"`
+
+	// Links to add to the entity.
+	Links []Link `yaml:"links"`
+
+	// Annotations to add to the entity. Values are templates.
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// Match describes entities. All given criteria must be fulfilled. An empty
+// Match matches all entities.
+type Match struct {
+	// Kinds of which the entity must have one, e.g. "Component".
+	Kinds []string `yaml:"kinds"`
+
+	// Types of which the entity must have one (spec.type).
+	Types []string `yaml:"types"`
+
+	// Tags that the entity must all have.
+	Tags []string `yaml:"tags"`
+
+	// Labels that the entity must have, with values matching the given
+	// regular expressions. A missing label is treated as empty.
+	Labels map[string]string `yaml:"labels"`
+}
+
+// Link is an entity link. URL and Title are templates.
+type Link struct {
+	// URL of the link (required). If it renders empty, the link is skipped.
+	URL   string `yaml:"url"`
+	Title string `yaml:"title"`
+	Icon  string `yaml:"icon"`
+	Type  string `yaml:"type"`
+}
+
+// File is the content of a rules file.
+type File struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Config holds the service configuration.
+type Config struct {
+	// Reader is the source to read rules from.
+	// If nil, FilePath must be set.
+	Reader io.Reader
+
+	// FilePath is the path to the rules file.
+	// Used if Reader is nil.
+	FilePath string
+}
+
+// Service provides rules file parsing functionality.
+type Service struct {
+	config Config
+}
+
+// New creates a new rules service.
+func New(c Config) (*Service, error) {
+	if c.Reader == nil && c.FilePath == "" {
+		return nil, microerror.Maskf(invalidConfigError, "either Reader or FilePath must be provided")
+	}
+
+	return &Service{
+		config: c,
+	}, nil
+}
+
+// Load reads, parses and compiles the rules.
+func (s *Service) Load() (*Rules, error) {
+	var reader io.Reader
+
+	if s.config.Reader != nil {
+		reader = s.config.Reader
+	} else {
+		file, err := os.Open(s.config.FilePath)
+		if err != nil {
+			return nil, microerror.Maskf(fileNotFoundError, "failed to open rules file: %v", err)
+		}
+		defer func() { _ = file.Close() }()
+		reader = file
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, microerror.Maskf(readError, "failed to read rules: %v", err)
+	}
+
+	return parse(data)
+}
+
+// Default returns the built-in default rules.
+func Default() (*Rules, error) {
+	return parse(defaultRules)
+}
+
+// LoadFile returns the rules from the file at the given path, or the
+// built-in default rules if path is empty.
+func LoadFile(path string) (*Rules, error) {
+	if path == "" {
+		return Default()
+	}
+
+	s, err := New(Config{FilePath: path})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return s.Load()
+}
+
+func parse(data []byte) (*Rules, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, microerror.Maskf(parseError, "failed to parse YAML: %v", err)
+	}
+
+	rules, err := compile(&f)
+	if err != nil {
+		return nil, microerror.Maskf(validationError, "%v", err)
+	}
+
+	return rules, nil
+}
+
+// Functions available in templates, in addition to the built-in ones.
+// Argument order follows the Sprig library, to allow for pipelines.
+var templateFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, replacement, s string) string { return strings.ReplaceAll(s, old, replacement) },
+}
+
+// compile validates the rules and compiles templates and expressions.
+func compile(f *File) (*Rules, error) {
+	rules := &Rules{}
+	names := make(map[string]bool, len(f.Rules))
+
+	for i, r := range f.Rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("rule %d: name is required", i+1)
+		}
+		if names[r.Name] {
+			return nil, fmt.Errorf("rule %q: defined more than once", r.Name)
+		}
+		names[r.Name] = true
+
+		if len(r.Links) == 0 && len(r.Annotations) == 0 {
+			return nil, fmt.Errorf("rule %q: at least one link or annotation is required", r.Name)
+		}
+
+		c := compiledRule{name: r.Name}
+
+		var err error
+		c.match, err = compileMatch(r.Match)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: match: %w", r.Name, err)
+		}
+		c.exclude, err = compileMatch(r.Exclude)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: exclude: %w", r.Name, err)
+		}
+		c.excludeEmpty = isEmptyMatch(r.Exclude)
+
+		for j, l := range r.Links {
+			if l.URL == "" {
+				return nil, fmt.Errorf("rule %q: link %d: url is required", r.Name, j+1)
+			}
+			url, err := newTemplate(l.URL)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: link %d: url: %w", r.Name, j+1, err)
+			}
+			title, err := newTemplate(l.Title)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: link %d: title: %w", r.Name, j+1, err)
+			}
+			c.links = append(c.links, compiledLink{url: url, title: title, icon: l.Icon, linkType: l.Type})
+		}
+
+		for key, value := range r.Annotations {
+			t, err := newTemplate(value)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: annotation %s: %w", r.Name, key, err)
+			}
+			if c.annotations == nil {
+				c.annotations = make(map[string]*template.Template)
+			}
+			c.annotations[key] = t
+		}
+
+		rules.rules = append(rules.rules, c)
+	}
+
+	return rules, nil
+}
+
+func compileMatch(m Match) (compiledMatch, error) {
+	c := compiledMatch{
+		kinds: m.Kinds,
+		types: m.Types,
+		tags:  m.Tags,
+	}
+
+	for label, expr := range m.Labels {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return c, fmt.Errorf("label %s: %w", label, err)
+		}
+		if c.labels == nil {
+			c.labels = make(map[string]*regexp.Regexp)
+		}
+		c.labels[label] = re
+	}
+
+	return c, nil
+}
+
+func isEmptyMatch(m Match) bool {
+	return len(m.Kinds) == 0 && len(m.Types) == 0 && len(m.Tags) == 0 && len(m.Labels) == 0
+}
+
+func newTemplate(text string) (*template.Template, error) {
+	return template.New("").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
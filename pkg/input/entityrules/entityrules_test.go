@@ -0,0 +1,218 @@
+package entityrules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+)
+
+func TestDefault(t *testing.T) {
+	rules, err := Default()
+	if err != nil {
+		t.Fatalf("Default() unexpected error %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		entity *bscatalog.Entity
+		want   []bscatalog.EntityLink
+	}{
+		{
+			name: "service component",
+			entity: &bscatalog.Entity{
+				Kind:     bscatalog.EntityKindComponent,
+				Metadata: bscatalog.EntityMetadata{Name: "cert-manager-app"},
+				Spec:     bscatalog.ComponentSpec{Type: "service"},
+			},
+			want: []bscatalog.EntityLink{
+				{
+					URL:   "https://giantswarm.grafana.net/d/eb617ba1-209a-4d57-9963-1af9a8ddc8d4/general-service-metrics?orgId=1&var-app=cert-manager&var-app=cert-manager-app&from=now-24h&to=now",
+					Title: "General service metrics dashboard",
+					Icon:  "dashboard",
+					Type:  "grafana-dashboard",
+				},
+			},
+		},
+		{
+			name: "library component",
+			entity: &bscatalog.Entity{
+				Kind:     bscatalog.EntityKindComponent,
+				Metadata: bscatalog.EntityMetadata{Name: "microerror"},
+				Spec:     bscatalog.ComponentSpec{Type: "library"},
+			},
+		},
+		{
+			name: "vintage installation",
+			entity: &bscatalog.Entity{
+				Kind: bscatalog.EntityKindResource,
+				Metadata: bscatalog.EntityMetadata{
+					Name:        "gauss",
+					Labels:      map[string]string{"giantswarm.io/provider": "aws"},
+					Annotations: map[string]string{"giantswarm.io/base": "gauss.eu-west-1.aws.gigantic.io"},
+				},
+				Spec: bscatalog.ResourceSpec{Type: "installation"},
+			},
+			want: []bscatalog.EntityLink{
+				{URL: "https://happa.g8s.gauss.eu-west-1.aws.gigantic.io/admin-login", Title: "Happa", Icon: "giantswarm"},
+				{URL: "https://grafana.g8s.gauss.eu-west-1.aws.gigantic.io/", Title: "Grafana", Icon: "grafana"},
+			},
+		},
+		{
+			name: "CAPI installation",
+			entity: &bscatalog.Entity{
+				Kind: bscatalog.EntityKindResource,
+				Metadata: bscatalog.EntityMetadata{
+					Name:        "golem",
+					Labels:      map[string]string{"giantswarm.io/provider": "capa"},
+					Annotations: map[string]string{"giantswarm.io/base": "gaws.gigantic.io"},
+				},
+				Spec: bscatalog.ResourceSpec{Type: "installation"},
+			},
+			want: []bscatalog.EntityLink{
+				{URL: "https://grafana-golem.teleport.giantswarm.io", Title: "Grafana (via Teleport)", Icon: "grafana"},
+				{URL: "https://grafana.golem.gaws.gigantic.io/", Title: "Grafana (customer URL)", Icon: "grafana"},
+				{URL: "https://happa.golem.gaws.gigantic.io/admin-login", Title: "Happa", Icon: "giantswarm"},
+				{URL: "https://kyverno-golem.teleport.giantswarm.io", Title: "Policy Reporter (via Teleport)", Icon: "dashboard"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := rules.Apply(tc.entity)
+			if err != nil {
+				t.Fatalf("Apply() unexpected error %v", err)
+			}
+			if diff := cmp.Diff(tc.want, tc.entity.Metadata.Links); diff != "" {
+				t.Errorf("Apply() links mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	s, err := New(Config{Reader: strings.NewReader(`rules:
+  - name: runbook
+    match:
+      tags: [helmchart, flavor:app]
+    exclude:
+      labels:
+        giantswarm.io/language: go
+    links:
+      - url: 'https://runbooks.example.com/{{ .Metadata.Name | upper }}'
+        title: 'Runbook for {{ .Metadata.Name }}'
+    annotations:
+      example.com/chart: '{{ index .Metadata.Annotations "giantswarm.io/helmcharts" }}'
+      example.com/existing: new value
+      example.com/empty: '{{ index .Metadata.Annotations "missing" }}'
+  - name: duplicate-link
+    links:
+      - url: 'https://runbooks.example.com/{{ upper .Metadata.Name }}'
+        title: Duplicate
+`)})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	rules, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error %v", err)
+	}
+
+	matching := &bscatalog.Entity{
+		Kind: bscatalog.EntityKindComponent,
+		Metadata: bscatalog.EntityMetadata{
+			Name: "my-app",
+			Tags: []string{"flavor:app", "helmchart", "other"},
+			Annotations: map[string]string{
+				"giantswarm.io/helmcharts": "gsoci.azurecr.io/charts/giantswarm/my-app",
+				"example.com/existing":     "old value",
+			},
+		},
+	}
+	if err := rules.Apply(matching); err != nil {
+		t.Fatalf("Apply() unexpected error %v", err)
+	}
+
+	want := bscatalog.EntityMetadata{
+		Name: "my-app",
+		Tags: []string{"flavor:app", "helmchart", "other"},
+		Annotations: map[string]string{
+			"giantswarm.io/helmcharts": "gsoci.azurecr.io/charts/giantswarm/my-app",
+			"example.com/existing":     "old value",
+			"example.com/chart":        "gsoci.azurecr.io/charts/giantswarm/my-app",
+		},
+		Links: []bscatalog.EntityLink{
+			{URL: "https://runbooks.example.com/MY-APP", Title: "Runbook for my-app"},
+		},
+	}
+	if diff := cmp.Diff(want, matching.Metadata); diff != "" {
+		t.Errorf("Apply() mismatch (-want +got):\n%s", diff)
+	}
+
+	excluded := &bscatalog.Entity{
+		Kind: bscatalog.EntityKindComponent,
+		Metadata: bscatalog.EntityMetadata{
+			Name:   "my-operator",
+			Tags:   []string{"flavor:app", "helmchart"},
+			Labels: map[string]string{"giantswarm.io/language": "go"},
+		},
+	}
+	if err := rules.Apply(excluded); err != nil {
+		t.Fatalf("Apply() unexpected error %v", err)
+	}
+
+	wantLinks := []bscatalog.EntityLink{
+		{URL: "https://runbooks.example.com/MY-OPERATOR", Title: "Duplicate"},
+	}
+	if diff := cmp.Diff(wantLinks, excluded.Metadata.Links); diff != "" {
+		t.Errorf("Apply() links mismatch (-want +got):\n%s", diff)
+	}
+	if len(excluded.Metadata.Annotations) > 0 {
+		t.Errorf("Apply() unexpected annotations %v", excluded.Metadata.Annotations)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "missing name",
+			content: "rules:\n  - links:\n      - url: https://example.com\n",
+			wantErr: "rule 1: name is required",
+		},
+		{
+			name:    "no links or annotations",
+			content: "rules:\n  - name: empty\n",
+			wantErr: `rule "empty": at least one link or annotation is required`,
+		},
+		{
+			name:    "invalid template",
+			content: "rules:\n  - name: broken\n    links:\n      - url: 'https://example.com/{{ .Metadata.Name'\n",
+			wantErr: `rule "broken": link 1: url`,
+		},
+		{
+			name:    "invalid label expression",
+			content: "rules:\n  - name: broken\n    match:\n      labels:\n        a: '('\n    annotations:\n      a: b\n",
+			wantErr: `rule "broken": match: label a`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := New(Config{Reader: strings.NewReader(tc.content)})
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			_, err = s.Load()
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Load() error = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/google/go-github/v90/github"
 	"go.yaml.in/yaml/v3"
 
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
 )
 
@@ -17,33 +18,46 @@ type Config struct {
 	// AuthToken is the GitHub authentication token.
 	// If empty, unauthenticated requests will be made (lower rate limits).
 	AuthToken string `json:"-"` //nolint:gosec // G117: not serialized, used only in-process
+
+	// Client is the GitHub client to use, e.g. one shared with other
+	// services. If nil, a new client is created using AuthToken.
+	Client *github.Client `json:"-"`
+
+	// Host is the GitHub instance URLs refer to. Defaults to github.com.
+	Host githubhost.Host `json:"-"`
 }
 
 // Service provides GitHub URL fetching functionality.
 type Service struct {
 	client *github.Client
+	host   githubhost.Host
 	ctx    context.Context
 }
 
 // New creates a new GitHub URL fetching service.
 func New(c Config) (*Service, error) {
 	ctx := context.Background()
-	client, err := httpclient.NewGitHubClient(c.AuthToken)
-	if err != nil {
-		return nil, err
+	client := c.Client
+	if client == nil {
+		var err error
+		client, err = httpclient.NewGitHubClient(c.AuthToken, httpclient.WithHost(c.Host))
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &Service{
 		client: client,
+		host:   c.Host,
 		ctx:    ctx,
 	}, nil
 }
 
 // FetchContent fetches the content from a GitHub URL.
-// Supports both blob URLs and raw.githubusercontent.com URLs.
+// Supports both blob URLs and raw URLs of the configured host.
 func (s *Service) FetchContent(url string) (string, error) {
 	// Parse the URL to extract owner, repo, ref, and path
-	owner, repo, ref, path, err := ParseGitHubURL(url)
+	owner, repo, ref, path, err := ParseGitHubURL(s.host, url)
 	if err != nil {
 		return "", microerror.Mask(err)
 	}
@@ -76,18 +90,27 @@ func (s *Service) FetchContent(url string) (string, error) {
 	return content, nil
 }
 
-// ParseGitHubURL extracts owner, repo, ref, and path from a GitHub URL.
-// Supports formats:
+// ParseGitHubURL extracts owner, repo, ref, and path from a URL of a file on
+// the given GitHub host. Supports formats:
 //   - https://github.com/owner/repo/blob/ref/path/to/file.yaml
 //   - https://raw.githubusercontent.com/owner/repo/ref/path/to/file.yaml
-func ParseGitHubURL(url string) (owner, repo, ref, path string, err error) {
+//
+// For GitHub Enterprise Server, raw URLs look like
+// https://github.example.com/raw/owner/repo/ref/path/to/file.yaml, or
+// https://raw.github.example.com/owner/repo/ref/path/to/file.yaml with
+// subdomain isolation.
+func ParseGitHubURL(host githubhost.Host, url string) (owner, repo, ref, path string, err error) {
 	// Normalize the URL
 	url = strings.TrimSpace(url)
 	url = strings.TrimPrefix(url, "https://")
 	url = strings.TrimPrefix(url, "http://")
 
-	// Handle raw.githubusercontent.com format
-	if remainder, found := strings.CutPrefix(url, "raw.githubusercontent.com/"); found {
+	// Handle raw format
+	for _, prefix := range host.RawURLPrefixes() {
+		remainder, found := strings.CutPrefix(url, prefix)
+		if !found {
+			continue
+		}
 		parts := strings.SplitN(remainder, "/", 4)
 		if len(parts) < 4 {
 			return "", "", "", "", microerror.Maskf(invalidURLError, "invalid raw GitHub URL format: expected owner/repo/ref/path")
@@ -98,8 +121,8 @@ func ParseGitHubURL(url string) (owner, repo, ref, path string, err error) {
 		return parts[0], parts[1], parts[2], parts[3], nil
 	}
 
-	// Handle github.com blob format
-	if remainder, found := strings.CutPrefix(url, "github.com/"); found {
+	// Handle blob format
+	if remainder, found := strings.CutPrefix(url, host.Name()+"/"); found {
 		parts := strings.SplitN(remainder, "/", 5)
 		if len(parts) < 5 {
 			return "", "", "", "", microerror.Maskf(invalidURLError, "invalid GitHub blob URL format: expected owner/repo/blob/ref/path")
@@ -113,7 +136,7 @@ func ParseGitHubURL(url string) (owner, repo, ref, path string, err error) {
 		return parts[0], parts[1], parts[3], parts[4], nil
 	}
 
-	return "", "", "", "", microerror.Maskf(invalidURLError, "unsupported URL format: must be github.com or raw.githubusercontent.com")
+	return "", "", "", "", microerror.Maskf(invalidURLError, "unsupported URL format: must be %s or %s", host.Name(), strings.TrimSuffix(host.RawURLPrefixes()[0], "/"))
 }
 
 // CRDMetadata contains metadata extracted from a CRD YAML.
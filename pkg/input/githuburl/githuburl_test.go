@@ -4,11 +4,14 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
 )
 
 func TestParseGitHubURL(t *testing.T) {
 	tests := []struct {
 		name      string
+		host      string
 		url       string
 		wantOwner string
 		wantRepo  string
@@ -119,10 +122,47 @@ func TestParseGitHubURL(t *testing.T) {
 			url:     "https://raw.githubusercontent.com/owner/repo/main/",
 			wantErr: true,
 		},
+		{
+			name:      "EnterpriseBlobURL",
+			host:      "github.example.com",
+			url:       "https://github.example.com/org/repo/blob/main/path/file.yaml",
+			wantOwner: "org",
+			wantRepo:  "repo",
+			wantRef:   "main",
+			wantPath:  "path/file.yaml",
+		},
+		{
+			name:      "EnterpriseRawURL",
+			host:      "github.example.com",
+			url:       "https://github.example.com/raw/org/repo/main/path/file.yaml",
+			wantOwner: "org",
+			wantRepo:  "repo",
+			wantRef:   "main",
+			wantPath:  "path/file.yaml",
+		},
+		{
+			name:      "EnterpriseRawURLSubdomain",
+			host:      "github.example.com",
+			url:       "https://raw.github.example.com/org/repo/main/path/file.yaml",
+			wantOwner: "org",
+			wantRepo:  "repo",
+			wantRef:   "main",
+			wantPath:  "path/file.yaml",
+		},
+		{
+			name:    "EnterpriseOtherHost",
+			host:    "github.example.com",
+			url:     "https://github.com/org/repo/blob/main/file.yaml",
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			owner, repo, ref, path, err := ParseGitHubURL(tt.url)
+			host, err := githubhost.New(tt.host)
+			if err != nil {
+				t.Fatalf("githubhost.New() unexpected error: %v", err)
+			}
+			owner, repo, ref, path, err := ParseGitHubURL(host, tt.url)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ParseGitHubURL() error = %v, wantErr %v", err, tt.wantErr)
 				return
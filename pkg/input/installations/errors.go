@@ -6,6 +6,14 @@ var invalidConfigError = &microerror.Error{
 	Kind: "invalidConfigError",
 }
 
+var readError = &microerror.Error{
+	Kind: "readError",
+}
+
+var parseError = &microerror.Error{
+	Kind: "parseError",
+}
+
 var fileNotFoundError = &microerror.Error{
 	Kind: "fileNotFoundError",
 }
@@ -3,7 +3,6 @@ package installations
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"net/http"
 	"path/filepath"
@@ -13,6 +12,7 @@ import (
 	"github.com/google/go-github/v90/github"
 	"go.yaml.in/yaml/v3"
 
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
 )
 
@@ -43,6 +43,13 @@ type Config struct {
 
 	// Github personal access token (PTA) to use for client authentication.
 	GithubAuthToken string
+
+	// GitHub client to use, e.g. one shared with other services. If nil,
+	// a new client is created using GithubAuthToken.
+	GithubClient *github.Client
+
+	// GitHub instance to use. Defaults to github.com.
+	GithubHost githubhost.Host
 }
 
 type Service struct {
@@ -58,15 +65,19 @@ func New(c Config) (*Service, error) {
 	if c.GithubRepositoryName == "" {
 		return nil, microerror.Maskf(invalidConfigError, "no Github repository name configured")
 	}
-	if c.GithubAuthToken == "" {
+	if c.GithubAuthToken == "" && c.GithubClient == nil {
 		log.Println("WARNING: No Github token given (env variable GITHUB_TOKEN not set)")
 	}
 
 	ctx := context.Background()
 
-	githubClient, err := httpclient.NewGitHubClient(c.GithubAuthToken)
-	if err != nil {
-		return nil, microerror.Mask(err)
+	githubClient := c.GithubClient
+	if githubClient == nil {
+		var err error
+		githubClient, err = httpclient.NewGitHubClient(c.GithubAuthToken, httpclient.WithHost(c.GithubHost))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
 	}
 
 	s := &Service{
@@ -122,12 +133,12 @@ func (s *Service) GetInstallations() ([]*Installation, error) {
 
 		content, err := fileContent.GetContent()
 		if err != nil {
-			log.Fatalf("INFO: error fetching content for file %s in repository %s/%s: %s", fullPath, s.config.GithubOrganization, s.config.GithubRepositoryName, err)
+			return nil, microerror.Maskf(readError, "error fetching content for file %s in repository %s/%s: %s", fullPath, s.config.GithubOrganization, s.config.GithubRepositoryName, err)
 		}
 
 		installation, err := parseInstallationInfo([]byte(content))
 		if err != nil {
-			log.Fatalf("WARNING: error parsing content for file %s: %s", fullPath, err)
+			return nil, microerror.Maskf(parseError, "error parsing content for file %s: %s", fullPath, err)
 		}
 
 		// Check if CA file exists
@@ -139,7 +150,7 @@ func (s *Service) GetInstallations() ([]*Installation, error) {
 			}
 		}
 		if response != nil && response.StatusCode == http.StatusOK {
-			installation.CustomCA = fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s", s.config.GithubOrganization, s.config.GithubRepositoryName, defaultBranch, caFilePath)
+			installation.CustomCA = s.config.GithubHost.BlobURL(s.config.GithubOrganization+"/"+s.config.GithubRepositoryName, defaultBranch, caFilePath)
 		}
 
 		// Check if access file exists
@@ -153,7 +164,7 @@ func (s *Service) GetInstallations() ([]*Installation, error) {
 		if response != nil && response.StatusCode == http.StatusOK {
 			installation.AccessMarkdown, err = fileContent.GetContent()
 			if err != nil {
-				log.Fatalf("INFO: error fetching content for file %s in repository %s/%s: %s", fullPath, s.config.GithubOrganization, s.config.GithubRepositoryName, err)
+				return nil, microerror.Maskf(readError, "error fetching content for file %s in repository %s/%s: %s", accessFilePath, s.config.GithubOrganization, s.config.GithubRepositoryName, err)
 			}
 		}
 
@@ -45,3 +45,15 @@ var couldNotReadConfigError = &microerror.Error{
 var couldNotUnmarshalConfigError = &microerror.Error{
 	Kind: "couldNotUnmarshalConfigError",
 }
+
+var couldNotSelectManifestError = &microerror.Error{
+	Kind: "couldNotSelectManifestError",
+}
+
+var couldNotFetchChartError = &microerror.Error{
+	Kind: "couldNotFetchChartError",
+}
+
+var couldNotReadChartError = &microerror.Error{
+	Kind: "couldNotReadChartError",
+}
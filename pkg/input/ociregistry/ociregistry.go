@@ -2,9 +2,16 @@
 package ociregistry
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"maps"
+	"os"
+	"path"
+	"slices"
 	"sort"
 	"strings"
 
@@ -14,6 +21,7 @@ import (
 	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
 	"oras.land/oras-go/v2/registry/remote/retry"
 )
 
@@ -23,8 +31,49 @@ type Registry struct {
 	registry *remote.Registry
 }
 
+// Environment variables giving registry credentials, taking precedence over
+// the Docker config file.
+const (
+	UsernameEnvVar = "OCI_REGISTRY_USERNAME"
+	PasswordEnvVar = "OCI_REGISTRY_PASSWORD"
+	TokenEnvVar    = "OCI_REGISTRY_TOKEN"
+)
+
 type Config struct {
 	Hostname string
+
+	// Credentials for the registry. If empty, credentials for the registry are
+	// looked up in the Docker config file, including its credential helpers.
+	// Without any credentials, the registry is accessed anonymously.
+	Credentials Credentials
+
+	// Path of the Docker config file. If empty, $DOCKER_CONFIG/config.json
+	// or ~/.docker/config.json is used, if it exists.
+	DockerConfigPath string
+}
+
+// Credentials for an OCI registry, either a username and password, or an
+// identity token as used by `docker login`, e.g. an Azure Container Registry
+// refresh token.
+type Credentials struct {
+	Username string
+	Password string
+	Token    string
+}
+
+// IsZero returns whether no credentials are given.
+func (c Credentials) IsZero() bool {
+	return c == Credentials{}
+}
+
+// CredentialsFromEnv reads registry credentials from the UsernameEnvVar,
+// PasswordEnvVar and TokenEnvVar environment variables.
+func CredentialsFromEnv() Credentials {
+	return Credentials{
+		Username: os.Getenv(UsernameEnvVar),
+		Password: os.Getenv(PasswordEnvVar),
+		Token:    os.Getenv(TokenEnvVar),
+	}
 }
 
 func NewRegistry(ctx context.Context, config Config) (*Registry, error) {
@@ -38,10 +87,15 @@ func NewRegistry(ctx context.Context, config Config) (*Registry, error) {
 		return nil, microerror.Maskf(couldNotCreateRegistryClientError, "error creating registry client: %v", err)
 	}
 
-	// Configure for anonymous access
+	credential, err := credentialFunc(config)
+	if err != nil {
+		return nil, microerror.Maskf(couldNotCreateRegistryClientError, "error reading registry credentials: %v", err)
+	}
+
 	reg.Client = &auth.Client{
-		Client: retry.DefaultClient,
-		Cache:  auth.NewCache(),
+		Client:     retry.DefaultClient,
+		Cache:      auth.NewCache(),
+		Credential: credential,
 	}
 	reg.PlainHTTP = false // Use HTTPS
 
@@ -51,6 +105,32 @@ func NewRegistry(ctx context.Context, config Config) (*Registry, error) {
 	}, nil
 }
 
+// credentialFunc returns the function providing credentials for the
+// registry, based on the credentials given in config or the Docker config
+// file.
+func credentialFunc(config Config) (auth.CredentialFunc, error) {
+	if !config.Credentials.IsZero() {
+		return auth.StaticCredential(config.Hostname, auth.Credential{
+			Username:     config.Credentials.Username,
+			Password:     config.Credentials.Password,
+			RefreshToken: config.Credentials.Token,
+		}), nil
+	}
+
+	var store *credentials.DynamicStore
+	var err error
+	if config.DockerConfigPath != "" {
+		store, err = credentials.NewStore(config.DockerConfigPath, credentials.StoreOptions{})
+	} else {
+		store, err = credentials.NewStoreFromDocker(credentials.StoreOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials.Credential(store), nil
+}
+
 // ListRepositories retrieves all repository names
 // starting with prefix from the registry.
 // Results are sorted alphabetically for stable output.
@@ -154,9 +234,57 @@ func LatestReleaseTag(tags []string) (string, bool) {
 	return "", false
 }
 
-// ManifestInfo contains both the config and manifest annotations
+// Media types not defined in the OCI image spec.
+const (
+	helmChartConfigMediaType    = "application/vnd.cncf.helm.config.v1+json"
+	helmChartContentMediaType   = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+	dockerManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+	dockerImageConfigMediaType  = "application/vnd.docker.container.image.v1+json"
+)
+
+// Prefixes of artifact and layer media types of signatures, as created by
+// cosign and notation.
+var signatureMediaTypePrefixes = []string{
+	"application/vnd.dev.cosign.",
+	"application/vnd.dev.sigstore.bundle.",
+	"application/vnd.cncf.notary.signature",
+}
+
+// Maximum number of nested indexes followed to reach a manifest.
+const maxIndexDepth = 3
+
+// Maximum size of a file read from a chart archive.
+const maxChartFileSize = 10 << 20
+
+// ArtifactKind classifies the artifact described by a manifest.
+type ArtifactKind string
+
+const (
+	ArtifactKindHelmChart ArtifactKind = "helm-chart"
+	ArtifactKindImage     ArtifactKind = "image"
+	ArtifactKindSignature ArtifactKind = "signature"
+	ArtifactKindOther     ArtifactKind = "other"
+)
+
+// ManifestInfo contains the manifest's artifact type, as well as the config
+// and annotations of Helm charts.
 type ManifestInfo struct {
-	Config      map[string]interface{}
+	// Media type of the manifest. For image indexes, that of the platform
+	// manifest followed.
+	MediaType string
+
+	// Artifact type of the manifest, or else the media type of its config.
+	ArtifactType string
+
+	Kind ArtifactKind
+
+	// Config blob of Helm charts. Nil for other kinds of artifacts.
+	Config map[string]interface{}
+
+	// Descriptor of the chart archive layer of Helm charts, if any.
+	ChartLayer *v1.Descriptor
+
+	// Annotations of the manifest, and of the index it was found in, if any.
 	Annotations map[string]string
 }
 
@@ -185,28 +313,37 @@ func (r *Registry) GetRepositoryManifest(ctx context.Context, repository, tag st
 	return manifestInfo, nil
 }
 
-// fetchManifestInfo fetches the config blob and annotations from a manifest
+// fetchManifestInfo fetches the manifest, following image indexes, and for
+// Helm charts the config blob.
 func fetchManifestInfo(ctx context.Context, repo registry.Repository, manifestDescriptor v1.Descriptor) (*ManifestInfo, error) {
-	// Fetch the manifest
-	manifestReader, err := repo.Fetch(ctx, manifestDescriptor)
+	manifest, mediaType, indexAnnotations, err := fetchManifest(ctx, repo, manifestDescriptor)
 	if err != nil {
-		return nil, microerror.Maskf(couldNotGetRepositoryManifestError, "error fetching manifest: %v", err)
+		return nil, err
 	}
-	defer func() {
-		_ = manifestReader.Close()
-	}()
 
-	// Read the manifest content
-	manifestBytes, err := io.ReadAll(manifestReader)
-	if err != nil {
-		return nil, microerror.Maskf(couldNotReadManifestError, "error reading manifest: %v", err)
+	annotations := indexAnnotations
+	if len(manifest.Annotations) > 0 {
+		annotations = make(map[string]string, len(indexAnnotations)+len(manifest.Annotations))
+		maps.Copy(annotations, indexAnnotations)
+		maps.Copy(annotations, manifest.Annotations)
+	}
+
+	artifactType, kind := classifyManifest(manifest)
+	info := &ManifestInfo{
+		MediaType:    mediaType,
+		ArtifactType: artifactType,
+		Kind:         kind,
+		Annotations:  annotations,
+	}
+	if kind != ArtifactKindHelmChart {
+		return info, nil
 	}
 
-	// Parse as OCI manifest
-	var manifest v1.Manifest
-	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
-		// Not a manifest (could be an index), skip config
-		return nil, microerror.Maskf(couldNotUnmarshalManifestError, "error unmarshalling manifest: %v", err)
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == helmChartContentMediaType {
+			info.ChartLayer = &layer
+			break
+		}
 	}
 
 	// Fetch the config blob using the config descriptor
@@ -229,10 +366,191 @@ func fetchManifestInfo(ctx context.Context, repo registry.Repository, manifestDe
 	if err := json.Unmarshal(configBytes, &configMap); err != nil {
 		return nil, microerror.Maskf(couldNotUnmarshalConfigError, "error unmarshalling config: %v", err)
 	}
+	info.Config = configMap
 
-	// Return both config and manifest annotations
-	return &ManifestInfo{
-		Config:      configMap,
-		Annotations: manifest.Annotations,
-	}, nil
+	return info, nil
+}
+
+// fetchManifest fetches the manifest described by descriptor. Image indexes
+// are followed to a platform manifest, see selectManifest. Returns the
+// manifest, its media type and the annotations of the indexes followed.
+func fetchManifest(ctx context.Context, repo registry.Repository, descriptor v1.Descriptor) (v1.Manifest, string, map[string]string, error) {
+	var indexAnnotations map[string]string
+	for range maxIndexDepth + 1 {
+		// Fetch the manifest or index
+		reader, err := repo.Fetch(ctx, descriptor)
+		if err != nil {
+			return v1.Manifest{}, "", nil, microerror.Maskf(couldNotGetRepositoryManifestError, "error fetching manifest: %v", err)
+		}
+		data, err := io.ReadAll(reader)
+		_ = reader.Close()
+		if err != nil {
+			return v1.Manifest{}, "", nil, microerror.Maskf(couldNotReadManifestError, "error reading manifest: %v", err)
+		}
+
+		// Registries may not report the media type, which is then taken from
+		// the content.
+		var content struct {
+			MediaType string          `json:"mediaType"`
+			Manifests []v1.Descriptor `json:"manifests"`
+		}
+		if err := json.Unmarshal(data, &content); err != nil {
+			return v1.Manifest{}, "", nil, microerror.Maskf(couldNotUnmarshalManifestError, "error unmarshalling manifest: %v", err)
+		}
+		mediaType := descriptor.MediaType
+		if mediaType == "" {
+			mediaType = content.MediaType
+		}
+
+		if !isIndex(mediaType) && (mediaType != "" || content.Manifests == nil) {
+			var manifest v1.Manifest
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				return v1.Manifest{}, "", nil, microerror.Maskf(couldNotUnmarshalManifestError, "error unmarshalling manifest: %v", err)
+			}
+			return manifest, mediaType, indexAnnotations, nil
+		}
+
+		var index v1.Index
+		if err := json.Unmarshal(data, &index); err != nil {
+			return v1.Manifest{}, "", nil, microerror.Maskf(couldNotUnmarshalManifestError, "error unmarshalling index: %v", err)
+		}
+		// Annotations of nested indexes take precedence.
+		if len(index.Annotations) > 0 {
+			merged := make(map[string]string, len(indexAnnotations)+len(index.Annotations))
+			maps.Copy(merged, indexAnnotations)
+			maps.Copy(merged, index.Annotations)
+			indexAnnotations = merged
+		}
+		descriptor, err = selectManifest(index)
+		if err != nil {
+			return v1.Manifest{}, "", nil, err
+		}
+	}
+
+	return v1.Manifest{}, "", nil, microerror.Maskf(couldNotSelectManifestError, "more than %d nested indexes", maxIndexDepth)
+}
+
+func isIndex(mediaType string) bool {
+	return mediaType == v1.MediaTypeImageIndex || mediaType == dockerManifestListMediaType
+}
+
+// selectManifest returns the descriptor of the manifest to follow in an
+// index: the one for linux/amd64 if present, else the first one that is not
+// an attestation.
+func selectManifest(index v1.Index) (v1.Descriptor, error) {
+	var candidates []v1.Descriptor
+	for _, m := range index.Manifests {
+		// Attestations added by Docker buildx have an unknown platform.
+		if m.Annotations["vnd.docker.reference.type"] == "attestation-manifest" ||
+			(m.Platform != nil && m.Platform.OS == "unknown") {
+			continue
+		}
+		if m.Platform != nil && m.Platform.OS == "linux" && m.Platform.Architecture == "amd64" {
+			return m, nil
+		}
+		candidates = append(candidates, m)
+	}
+	if len(candidates) == 0 {
+		return v1.Descriptor{}, microerror.Maskf(couldNotSelectManifestError, "index contains no manifest to follow")
+	}
+	return candidates[0], nil
+}
+
+// classifyManifest returns the artifact type of the manifest, or else the
+// media type of its config, and the kind of artifact.
+func classifyManifest(manifest v1.Manifest) (string, ArtifactKind) {
+	artifactType := manifest.ArtifactType
+	if artifactType == "" {
+		artifactType = manifest.Config.MediaType
+	}
+
+	switch {
+	case artifactType == helmChartConfigMediaType || manifest.Config.MediaType == helmChartConfigMediaType:
+		return artifactType, ArtifactKindHelmChart
+	case isSignature(artifactType) || slices.ContainsFunc(manifest.Layers, func(l v1.Descriptor) bool { return isSignature(l.MediaType) }):
+		// Cosign signatures use an image config, with signature layers.
+		return artifactType, ArtifactKindSignature
+	case artifactType == v1.MediaTypeImageConfig || artifactType == dockerImageConfigMediaType:
+		return artifactType, ArtifactKindImage
+	default:
+		return artifactType, ArtifactKindOther
+	}
+}
+
+func isSignature(mediaType string) bool {
+	for _, prefix := range signatureMediaTypePrefixes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetChartFiles downloads the chart archive layer of a chart and returns the
+// contents of the files with the given names in the chart's directory, by
+// name. Files of subcharts are not considered. Files not found are missing
+// from the result.
+func (r *Registry) GetChartFiles(ctx context.Context, repository string, layer v1.Descriptor, names ...string) (map[string][]byte, error) {
+	repo, err := r.registry.Repository(ctx, repository)
+	if err != nil {
+		return nil, microerror.Maskf(couldNotGetRepositoryError, "error getting repository: %v", err)
+	}
+
+	reader, err := repo.Fetch(ctx, layer)
+	if err != nil {
+		return nil, microerror.Maskf(couldNotFetchChartError, "error fetching chart archive: %v", err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	files, err := readChartFiles(reader, names)
+	if err != nil {
+		return nil, microerror.Maskf(couldNotReadChartError, "error reading chart archive: %v", err)
+	}
+
+	return files, nil
+}
+
+// readChartFiles reads the files with the given names from the top-level
+// directory of a gzipped chart archive, which is named after the chart.
+func readChartFiles(r io.Reader, names []string) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = gz.Close()
+	}()
+
+	files := make(map[string][]byte)
+	archive := tar.NewReader(gz)
+	for len(files) < len(names) {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		_, name, found := strings.Cut(path.Clean(header.Name), "/")
+		if !found || !slices.Contains(names, name) {
+			continue
+		}
+		if header.Size > maxChartFileSize {
+			return nil, fmt.Errorf("%s is larger than %d bytes", header.Name, maxChartFileSize)
+		}
+
+		data, err := io.ReadAll(archive)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = data
+	}
+
+	return files, nil
 }
@@ -1,11 +1,25 @@
 package ociregistry
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/giantswarm/microerror"
 	"github.com/google/go-cmp/cmp"
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote/auth"
 )
 
 func TestNewRegistry(t *testing.T) {
@@ -126,6 +140,92 @@ func TestNewRegistry(t *testing.T) {
 	}
 }
 
+func TestCredentialFunc(t *testing.T) {
+	dir := t.TempDir()
+	dockerConfig := filepath.Join(dir, "config.json")
+	err := os.WriteFile(dockerConfig, []byte(`{"auths": {"registry.example.com": {"auth": "ZG9ja2VyOnNlY3JldA=="}}}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalidDockerConfig := filepath.Join(dir, "invalid.json")
+	err = os.WriteFile(invalidDockerConfig, []byte(`{`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		hostport string
+		want     auth.Credential
+		wantErr  bool
+	}{
+		{
+			name:     "UsernamePassword",
+			config:   Config{Hostname: "registry.example.com", Credentials: Credentials{Username: "user", Password: "pass"}, DockerConfigPath: dockerConfig},
+			hostport: "registry.example.com",
+			want:     auth.Credential{Username: "user", Password: "pass"},
+		},
+		{
+			name:     "Token",
+			config:   Config{Hostname: "registry.example.com", Credentials: Credentials{Token: "token"}},
+			hostport: "registry.example.com",
+			want:     auth.Credential{RefreshToken: "token"},
+		},
+		{
+			name:     "CredentialsForOtherRegistry",
+			config:   Config{Hostname: "registry.example.com", Credentials: Credentials{Token: "token"}},
+			hostport: "other.example.com",
+			want:     auth.EmptyCredential,
+		},
+		{
+			name:     "DockerConfig",
+			config:   Config{Hostname: "registry.example.com", DockerConfigPath: dockerConfig},
+			hostport: "registry.example.com",
+			want:     auth.Credential{Username: "docker", Password: "secret"},
+		},
+		{
+			name:     "DockerConfigWithoutRegistry",
+			config:   Config{Hostname: "other.example.com", DockerConfigPath: dockerConfig},
+			hostport: "other.example.com",
+			want:     auth.EmptyCredential,
+		},
+		{
+			name:     "MissingDockerConfig",
+			config:   Config{Hostname: "registry.example.com", DockerConfigPath: filepath.Join(dir, "missing.json")},
+			hostport: "registry.example.com",
+			want:     auth.EmptyCredential,
+		},
+		{
+			name:    "InvalidDockerConfig",
+			config:  Config{Hostname: "registry.example.com", DockerConfigPath: invalidDockerConfig},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential, err := credentialFunc(tt.config)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("credentialFunc() expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("credentialFunc() unexpected error: %v", err)
+			}
+
+			got, err := credential(context.Background(), tt.hostport)
+			if err != nil {
+				t.Fatalf("credential() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("credential() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestSortTagsBySemver(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -281,3 +381,196 @@ func TestLatestReleaseTag(t *testing.T) {
 		})
 	}
 }
+
+// fakeRepository serves content by digest.
+type fakeRepository struct {
+	registry.Repository
+	blobs map[digest.Digest][]byte
+}
+
+func (r *fakeRepository) add(t *testing.T, mediaType string, value any) v1.Descriptor {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	descriptor := content.NewDescriptorFromBytes(mediaType, data)
+	r.blobs[descriptor.Digest] = data
+	return descriptor
+}
+
+func (r *fakeRepository) Fetch(ctx context.Context, target v1.Descriptor) (io.ReadCloser, error) {
+	data, ok := r.blobs[target.Digest]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", target.Digest)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func TestFetchManifestInfo(t *testing.T) {
+	repo := &fakeRepository{blobs: map[digest.Digest][]byte{}}
+
+	chartConfig := repo.add(t, helmChartConfigMediaType, map[string]any{"name": "hello-world", "version": "1.0.0"})
+	chartLayer := v1.Descriptor{MediaType: helmChartContentMediaType, Digest: digest.FromString("chart"), Size: 5}
+	chart := repo.add(t, v1.MediaTypeImageManifest, v1.Manifest{
+		MediaType:   v1.MediaTypeImageManifest,
+		Config:      chartConfig,
+		Layers:      []v1.Descriptor{chartLayer},
+		Annotations: map[string]string{"org.opencontainers.image.title": "hello-world"},
+	})
+
+	// Image configs are not fetched, so they don't need to be served.
+	imageConfig := v1.Descriptor{MediaType: v1.MediaTypeImageConfig, Digest: digest.FromString("config"), Size: 6}
+	amd64 := repo.add(t, v1.MediaTypeImageManifest, v1.Manifest{
+		MediaType:   v1.MediaTypeImageManifest,
+		Config:      imageConfig,
+		Annotations: map[string]string{"platform": "amd64"},
+	})
+	amd64.Platform = &v1.Platform{OS: "linux", Architecture: "amd64"}
+	arm64 := repo.add(t, v1.MediaTypeImageManifest, v1.Manifest{MediaType: v1.MediaTypeImageManifest, Config: imageConfig})
+	arm64.Platform = &v1.Platform{OS: "linux", Architecture: "arm64"}
+	attestation := repo.add(t, v1.MediaTypeImageManifest, v1.Manifest{MediaType: v1.MediaTypeImageManifest, Config: imageConfig})
+	attestation.Platform = &v1.Platform{OS: "unknown", Architecture: "unknown"}
+
+	index := repo.add(t, v1.MediaTypeImageIndex, v1.Index{
+		MediaType:   v1.MediaTypeImageIndex,
+		Manifests:   []v1.Descriptor{attestation, arm64, amd64},
+		Annotations: map[string]string{"platform": "all", "org.opencontainers.image.source": "https://github.com/giantswarm/hello-world"},
+	})
+	indexWithoutMediaType := index
+	indexWithoutMediaType.MediaType = ""
+	attestationsOnly := repo.add(t, v1.MediaTypeImageIndex, v1.Index{MediaType: v1.MediaTypeImageIndex, Manifests: []v1.Descriptor{attestation}})
+
+	signature := repo.add(t, v1.MediaTypeImageManifest, v1.Manifest{
+		MediaType: v1.MediaTypeImageManifest,
+		Config:    imageConfig,
+		Layers:    []v1.Descriptor{{MediaType: "application/vnd.dev.cosign.simplesigning.v1+json", Digest: digest.FromString("sig"), Size: 3}},
+	})
+	sbom := repo.add(t, v1.MediaTypeImageManifest, v1.Manifest{
+		MediaType:    v1.MediaTypeImageManifest,
+		ArtifactType: "application/spdx+json",
+		Config:       v1.DescriptorEmptyJSON,
+	})
+
+	imageInfo := &ManifestInfo{
+		MediaType:    v1.MediaTypeImageManifest,
+		ArtifactType: v1.MediaTypeImageConfig,
+		Kind:         ArtifactKindImage,
+		Annotations:  map[string]string{"platform": "amd64", "org.opencontainers.image.source": "https://github.com/giantswarm/hello-world"},
+	}
+
+	tests := []struct {
+		name        string
+		descriptor  v1.Descriptor
+		want        *ManifestInfo
+		wantErrType error
+	}{
+		{
+			name:       "HelmChart",
+			descriptor: chart,
+			want: &ManifestInfo{
+				MediaType:    v1.MediaTypeImageManifest,
+				ArtifactType: helmChartConfigMediaType,
+				Kind:         ArtifactKindHelmChart,
+				Config:       map[string]interface{}{"name": "hello-world", "version": "1.0.0"},
+				ChartLayer:   &chartLayer,
+				Annotations:  map[string]string{"org.opencontainers.image.title": "hello-world"},
+			},
+		},
+		{
+			name:       "ImageIndex",
+			descriptor: index,
+			want:       imageInfo,
+		},
+		{
+			name:       "ImageIndexWithoutMediaType",
+			descriptor: indexWithoutMediaType,
+			want:       imageInfo,
+		},
+		{
+			name:        "ImageIndexWithAttestationsOnly",
+			descriptor:  attestationsOnly,
+			wantErrType: couldNotSelectManifestError,
+		},
+		{
+			name:       "CosignSignature",
+			descriptor: signature,
+			want: &ManifestInfo{
+				MediaType:    v1.MediaTypeImageManifest,
+				ArtifactType: v1.MediaTypeImageConfig,
+				Kind:         ArtifactKindSignature,
+			},
+		},
+		{
+			name:       "SBOM",
+			descriptor: sbom,
+			want: &ManifestInfo{
+				MediaType:    v1.MediaTypeImageManifest,
+				ArtifactType: "application/spdx+json",
+				Kind:         ArtifactKindOther,
+			},
+		},
+		{
+			name:        "MissingManifest",
+			descriptor:  content.NewDescriptorFromBytes(v1.MediaTypeImageManifest, []byte("{}")),
+			wantErrType: couldNotGetRepositoryManifestError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fetchManifestInfo(context.Background(), repo, tt.descriptor)
+			if tt.wantErrType != nil {
+				if microerror.Cause(err) != tt.wantErrType {
+					t.Errorf("fetchManifestInfo() error = %v, want %v", err, tt.wantErrType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchManifestInfo() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("fetchManifestInfo() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadChartFiles(t *testing.T) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for _, file := range []struct{ name, content string }{
+		{"hello-world/Chart.yaml", "name: hello-world"},
+		{"hello-world/charts/sub/values.schema.json", `{"title": "sub"}`},
+		{"hello-world/values.schema.json", `{"title": "hello-world"}`},
+		{"hello-world/templates/README.md", "not the chart README"},
+	} {
+		err := tw.WriteHeader(&tar.Header{Name: file.name, Mode: 0600, Size: int64(len(file.content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tw.Write([]byte(file.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readChartFiles(bytes.NewReader(archive.Bytes()), []string{"values.schema.json", "README.md"})
+	if err != nil {
+		t.Fatalf("readChartFiles() unexpected error: %v", err)
+	}
+	want := map[string][]byte{"values.schema.json": []byte(`{"title": "hello-world"}`)}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("readChartFiles() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := readChartFiles(strings.NewReader("not gzipped"), []string{"values.schema.json"}); err == nil {
+		t.Errorf("readChartFiles() expected error for invalid archive")
+	}
+}
@@ -18,3 +18,17 @@ func IsFileNotFoundError(err error) bool {
 var repositoryNotFoundError = &microerror.Error{
 	Kind: "repositoryNotFoundError",
 }
+
+var graphqlQueryError = &microerror.Error{
+	Kind: "graphqlQueryError",
+}
+
+var invalidListError = &microerror.Error{
+	Kind: "invalidListError",
+}
+
+// IsInvalidListError returns true if error is invalidListError, meaning
+// that repository lists don't match the schema.
+func IsInvalidListError(err error) bool {
+	return microerror.Cause(err) == invalidListError
+}
@@ -0,0 +1,401 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// Number of repositories to probe in a single GraphQL query.
+	graphqlBatchSize = 50
+
+	// Paths probed in every repository, relative to the default branch.
+	circleciConfigPath = ".circleci/config.yml"
+	readmePath         = "README.md"
+	helmPath           = "helm"
+)
+
+// catalogInfoPaths are the locations of hand-maintained catalog-info files
+// within a repository, in the order of preference.
+var catalogInfoPaths = []string{
+	"catalog-info.yaml",
+	".backstage/catalog-info.yaml",
+}
+
+// codeOwnersPaths are the locations of CODEOWNERS files within a repository,
+// in the order in which GitHub looks them up.
+var codeOwnersPaths = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// graphqlRequest is the body of a request to the GitHub GraphQL API.
+type graphqlRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+type graphqlError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// graphqlRepository holds the objects requested per repository. Which
+// fields are filled depends on the query issued.
+type graphqlRepository struct {
+	CircleCI *graphqlObject
+	Readme   *graphqlObject
+	Helm     *graphqlObject
+
+	// Tree of .github/workflows, with blob texts.
+	Workflows *graphqlObject
+
+	// Catalog-info blobs, in the order of catalogInfoPaths.
+	CatalogInfoFiles []*graphqlObject
+
+	// CODEOWNERS blobs, in the order of codeOwnersPaths.
+	CodeOwnersFiles []*graphqlObject
+
+	// Chart.yaml blobs, keyed by the alias "chart<index>".
+	ChartFiles map[string]*graphqlObject
+}
+
+// graphqlObject is a git object (blob or tree). Text is only set for blobs,
+// Entries only for trees.
+type graphqlObject struct {
+	Text    *string            `json:"text"`
+	Entries []graphqlTreeEntry `json:"entries"`
+}
+
+type graphqlTreeEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+
+	// Only requested for workflow files.
+	Object *graphqlObject `json:"object"`
+}
+
+// PrefetchContentDetails loads the content details for the given repositories
+// via the GitHub GraphQL API, batching many repositories into one query.
+// This includes the Chart.yaml files of all Helm charts found.
+//
+// Repositories that could not be probed are left out of the cache, so that
+// the getter methods fall back to the REST API for them. A failing batch
+// doesn't stop the remaining ones; the errors of all failing batches are
+// returned. Repositories with known content details, e.g. from the details
+// snapshot, are skipped.
+func (s *Service) PrefetchContentDetails(names []string) error {
+	if s.config.Offline {
+		return nil
+	}
+
+	s.mu.RLock()
+	names = slices.DeleteFunc(slices.Clone(names), func(name string) bool {
+		_, known := s.githubRepoContentDetails[name]
+		return known
+	})
+	s.mu.RUnlock()
+
+	var errs []error
+	for start := 0; start < len(names); start += graphqlBatchSize {
+		end := min(start+graphqlBatchSize, len(names))
+		batch := names[start:end]
+
+		details, err := s.probeContentDetails(batch)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("repositories %s to %s: %w", batch[0], batch[len(batch)-1], err))
+			continue
+		}
+
+		s.mu.Lock()
+		for name, d := range details {
+			s.githubRepoContentDetails[name] = d
+		}
+		s.mu.Unlock()
+	}
+
+	return microerror.Mask(errors.Join(errs...))
+}
+
+// probeContentDetails issues the queries for one batch of repositories.
+// The first query detects CircleCI, README, Helm charts, workflows,
+// catalog-info and CODEOWNERS files, the second one fetches the Chart.yaml files of all charts detected.
+func (s *Service) probeContentDetails(names []string) (map[string]GithubRepoContentDetails, error) {
+	variables := map[string]any{"owner": s.config.GithubOrganization}
+	for i, name := range names {
+		variables[fmt.Sprintf("n%d", i)] = name
+	}
+
+	repos, err := s.graphqlQuery(buildContentQuery(len(names)), variables)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]GithubRepoContentDetails)
+	var chartRepos []string
+	var chartNames [][]string
+	for i, name := range names {
+		repo := repos[fmt.Sprintf("r%d", i)]
+		if repo == nil {
+			continue
+		}
+
+		details := contentDetailsFromGraphQL(s.config.GithubOrganization, repo)
+		if details.ForcePublicRegistry {
+			log.Printf("DEBUG - %s - CircleCI config has force-public: true in push-to-registries\n", name)
+		}
+		result[name] = details
+
+		var dirs []string
+		for _, entry := range repo.Helm.entries() {
+			if entry.Type == "tree" {
+				dirs = append(dirs, entry.Name)
+			}
+		}
+		if len(dirs) > 0 {
+			chartRepos = append(chartRepos, name)
+			chartNames = append(chartNames, dirs)
+		}
+	}
+
+	if len(chartRepos) == 0 {
+		return result, nil
+	}
+
+	variables = map[string]any{"owner": s.config.GithubOrganization}
+	for i, name := range chartRepos {
+		variables[fmt.Sprintf("n%d", i)] = name
+		for j, chartName := range chartNames[i] {
+			variables[fmt.Sprintf("e%d_%d", i, j)] = fmt.Sprintf("HEAD:%s/%s/Chart.yaml", helmPath, chartName)
+		}
+	}
+
+	repos, err = s.graphqlQuery(buildChartFileQuery(chartNames), variables)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, name := range chartRepos {
+		repo := repos[fmt.Sprintf("r%d", i)]
+		if repo == nil {
+			// Without chart files, let the REST API handle this repo.
+			delete(result, name)
+			continue
+		}
+
+		details := result[name]
+		details.HelmChartFiles = make(map[string]string)
+		for j, chartName := range chartNames[i] {
+			if text := repo.ChartFiles[fmt.Sprintf("chart%d", j)].text(); text != nil {
+				details.HelmChartFiles[chartName] = *text
+			}
+		}
+		result[name] = details
+	}
+
+	return result, nil
+}
+
+// graphqlQuery sends a query to the GitHub GraphQL API and returns the
+// repository results keyed by alias. A repository is nil if it could not
+// be resolved. Errors reported for individual repositories are logged, but
+// don't fail the query.
+func (s *Service) graphqlQuery(query string, variables map[string]any) (map[string]*graphqlRepository, error) {
+	req, err := s.githubClient.NewRequest(s.ctx, http.MethodPost, s.graphqlURL, &graphqlRequest{
+		Query:     query,
+		Variables: variables,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var raw struct {
+		Data   map[string]rawGraphqlRepository `json:"data"`
+		Errors []graphqlError                  `json:"errors"`
+	}
+	_, err = s.githubClient.Do(req, &raw)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if raw.Data == nil && len(raw.Errors) > 0 {
+		return nil, microerror.Maskf(graphqlQueryError, "%s", raw.Errors[0].Message)
+	}
+
+	for _, e := range raw.Errors {
+		log.Printf("WARN - GraphQL error (%s): %s", e.Type, e.Message)
+	}
+
+	repos := make(map[string]*graphqlRepository, len(raw.Data))
+	for alias, r := range raw.Data {
+		repos[alias] = r.toRepository()
+	}
+
+	return repos, nil
+}
+
+// rawGraphqlRepository is the undecoded form of a repository result, needed
+// to collect the dynamically aliased chart file objects.
+type rawGraphqlRepository map[string]*graphqlObject
+
+func (r rawGraphqlRepository) toRepository() *graphqlRepository {
+	if r == nil {
+		return nil
+	}
+
+	repo := &graphqlRepository{
+		CircleCI:   r["circleci"],
+		Readme:     r["readme"],
+		Helm:       r["helm"],
+		Workflows:  r["workflows"],
+		ChartFiles: make(map[string]*graphqlObject),
+	}
+	for i := range catalogInfoPaths {
+		repo.CatalogInfoFiles = append(repo.CatalogInfoFiles, r[fmt.Sprintf("cataloginfo%d", i)])
+	}
+	for i := range codeOwnersPaths {
+		repo.CodeOwnersFiles = append(repo.CodeOwnersFiles, r[fmt.Sprintf("codeowners%d", i)])
+	}
+	for alias, obj := range r {
+		if strings.HasPrefix(alias, "chart") {
+			repo.ChartFiles[alias] = obj
+		}
+	}
+
+	return repo
+}
+
+func (o *graphqlObject) text() *string {
+	if o == nil {
+		return nil
+	}
+	return o.Text
+}
+
+func (o *graphqlObject) entries() []graphqlTreeEntry {
+	if o == nil {
+		return nil
+	}
+	return o.Entries
+}
+
+// blobTexts returns the texts of the given blobs, keyed by path. The
+// result is never nil.
+func blobTexts(paths []string, objects []*graphqlObject) map[string]string {
+	result := make(map[string]string)
+	for i, obj := range objects {
+		if text := obj.text(); text != nil {
+			result[paths[i]] = *text
+		}
+	}
+	return result
+}
+
+// buildContentQuery returns a query probing CircleCI config, README, Helm
+// folder, GitHub Actions workflows, catalog-info and CODEOWNERS files for numRepos repositories, given as variables n0, n1, ...
+func buildContentQuery(numRepos int) string {
+	var b strings.Builder
+
+	b.WriteString("query($owner: String!")
+	for i := range numRepos {
+		fmt.Fprintf(&b, ", $n%d: String!", i)
+	}
+	b.WriteString(") {\n")
+
+	for i := range numRepos {
+		fmt.Fprintf(&b, "  r%d: repository(owner: $owner, name: $n%d) {\n", i, i)
+		fmt.Fprintf(&b, "    circleci: object(expression: %q) { ... on Blob { text } }\n", "HEAD:"+circleciConfigPath)
+		fmt.Fprintf(&b, "    readme: object(expression: %q) { __typename }\n", "HEAD:"+readmePath)
+		fmt.Fprintf(&b, "    helm: object(expression: %q) { ... on Tree { entries { name type } } }\n", "HEAD:"+helmPath)
+		fmt.Fprintf(&b, "    workflows: object(expression: %q) { ... on Tree { entries { name type object { ... on Blob { text } } } } }\n", "HEAD:"+githubWorkflowsPath)
+		for j, path := range catalogInfoPaths {
+			fmt.Fprintf(&b, "    cataloginfo%d: object(expression: %q) { ... on Blob { text } }\n", j, "HEAD:"+path)
+		}
+		for j, path := range codeOwnersPaths {
+			fmt.Fprintf(&b, "    codeowners%d: object(expression: %q) { ... on Blob { text } }\n", j, "HEAD:"+path)
+		}
+		b.WriteString("  }\n")
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// buildChartFileQuery returns a query fetching Chart.yaml blobs. The outer
+// slice is indexed by repository (variables n0, n1, ...), the inner slice
+// by chart (expression variables e<repo>_<chart>).
+func buildChartFileQuery(chartNames [][]string) string {
+	var b strings.Builder
+
+	b.WriteString("query($owner: String!")
+	for i, charts := range chartNames {
+		fmt.Fprintf(&b, ", $n%d: String!", i)
+		for j := range charts {
+			fmt.Fprintf(&b, ", $e%d_%d: String!", i, j)
+		}
+	}
+	b.WriteString(") {\n")
+
+	for i, charts := range chartNames {
+		fmt.Fprintf(&b, "  r%d: repository(owner: $owner, name: $n%d) {\n", i, i)
+		for j := range charts {
+			fmt.Fprintf(&b, "    chart%d: object(expression: $e%d_%d) { ... on Blob { text } }\n", j, i, j)
+		}
+		b.WriteString("  }\n")
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// contentDetailsFromGraphQL converts a probe result into content details,
+// matching what loadGithubRepoContentDetails finds via the REST API.
+func contentDetailsFromGraphQL(organization string, repo *graphqlRepository) GithubRepoContentDetails {
+	details := GithubRepoContentDetails{}
+
+	if repo.CircleCI != nil {
+		details.HasCircleCI = true
+		if text := repo.CircleCI.text(); text != nil {
+			details.ForcePublicRegistry = circleciConfigHasForcePublic(*text)
+		}
+	}
+
+	if repo.Readme != nil {
+		details.HasReadme = true
+	}
+
+	if entries := repo.Helm.entries(); entries != nil {
+		details.HasHelmFolder = true
+		details.NumHelmCharts = len(entries)
+		details.HelmChartNames = make([]string, len(entries))
+		for i, entry := range entries {
+			details.HelmChartNames[i] = entry.Name
+		}
+	}
+
+	var workflows []string
+	for _, entry := range repo.Workflows.entries() {
+		if entry.Type != "blob" || !isWorkflowFile(entry.Name) {
+			continue
+		}
+		details.GithubWorkflowNames = append(details.GithubWorkflowNames, entry.Name)
+		if text := entry.Object.text(); text != nil {
+			workflows = append(workflows, *text)
+		}
+	}
+	details.ReusableWorkflows = reusableWorkflows(organization, workflows)
+
+	details.CatalogInfoFiles = blobTexts(catalogInfoPaths, repo.CatalogInfoFiles)
+	details.CodeOwnersFiles = blobTexts(codeOwnersPaths, repo.CodeOwnersFiles)
+
+	return details
+}
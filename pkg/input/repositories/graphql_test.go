@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v90/github"
+)
+
+const contentProbeResponse = `{
+  "data": {
+    "r0": {
+      "circleci": {"text": "workflows:\n  build:\n    jobs:\n      - architect/push-to-registries:\n          force-public: true\n"},
+      "readme": {"__typename": "Blob"},
+      "helm": {"entries": [{"name": "chart-a", "type": "tree"}, {"name": "README.md", "type": "blob"}]},
+      "workflows": {"entries": [
+        {"name": "zz_generated.create_release.yaml", "type": "blob", "object": {"text": "jobs:\n  release:\n    uses: giantswarm/github-workflows/.github/workflows/create-release.yaml@v1\n"}},
+        {"name": "README.md", "type": "blob", "object": {"text": "# Workflows\n"}}
+      ]},
+      "cataloginfo0": null,
+      "cataloginfo1": {"text": "kind: Component\n"},
+      "codeowners0": null,
+      "codeowners1": {"text": "* @giantswarm/team-a\n"},
+      "codeowners2": {"text": "* @giantswarm/team-b\n"}
+    },
+    "r1": {"circleci": null, "readme": null, "helm": null},
+    "r2": null
+  },
+  "errors": [{"type": "NOT_FOUND", "message": "Could not resolve to a Repository with the name 'giantswarm/repo-c'."}]
+}`
+
+const chartFileResponse = `{
+  "data": {
+    "r0": {"chart0": {"text": "name: chart-a\nversion: 1.2.3\n"}}
+  }
+}`
+
+func TestPrefetchContentDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			t.Errorf("unexpected request path %q", r.URL.Path)
+		}
+
+		var req graphqlRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("could not decode request: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(req.Query, "chart0:") {
+			if got := req.Variables["e0_0"]; got != "HEAD:helm/chart-a/Chart.yaml" {
+				t.Errorf("unexpected chart file expression %v", got)
+			}
+			_, _ = w.Write([]byte(chartFileResponse))
+			return
+		}
+		_, _ = w.Write([]byte(contentProbeResponse))
+	}))
+	defer server.Close()
+
+	baseURL := server.URL + "/"
+	client, err := github.NewClient(github.WithURLs(&baseURL, &baseURL))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	s := &Service{
+		config:                   Config{GithubOrganization: "giantswarm"},
+		ctx:                      context.Background(),
+		githubClient:             client,
+		graphqlURL:               server.URL + "/graphql",
+		githubRepoContentDetails: make(map[string]GithubRepoContentDetails),
+	}
+
+	err = s.PrefetchContentDetails([]string{"repo-a", "repo-b", "repo-c"})
+	if err != nil {
+		t.Fatalf("PrefetchContentDetails() unexpected error %v", err)
+	}
+
+	want := map[string]GithubRepoContentDetails{
+		"repo-a": {
+			HasCircleCI:         true,
+			ForcePublicRegistry: true,
+			HasReadme:           true,
+			HasHelmFolder:       true,
+			NumHelmCharts:       2,
+			HelmChartNames:      []string{"chart-a", "README.md"},
+			HelmChartFiles:      map[string]string{"chart-a": "name: chart-a\nversion: 1.2.3\n"},
+			GithubWorkflowNames: []string{"zz_generated.create_release.yaml"},
+			ReusableWorkflows:   []string{"create-release.yaml"},
+			CatalogInfoFiles:    map[string]string{".backstage/catalog-info.yaml": "kind: Component\n"},
+			CodeOwnersFiles: map[string]string{
+				"CODEOWNERS":      "* @giantswarm/team-a\n",
+				"docs/CODEOWNERS": "* @giantswarm/team-b\n",
+			},
+		},
+		"repo-b": {
+			CatalogInfoFiles: map[string]string{},
+			CodeOwnersFiles:  map[string]string{},
+		},
+	}
+	if diff := cmp.Diff(want, s.githubRepoContentDetails); diff != "" {
+		t.Errorf("PrefetchContentDetails() mismatch (-want +got):\n%s", diff)
+	}
+
+	content, err := s.LoadHelmChartFile("repo-a", "chart-a")
+	if err != nil {
+		t.Fatalf("LoadHelmChartFile() unexpected error %v", err)
+	}
+	if content != "name: chart-a\nversion: 1.2.3\n" {
+		t.Errorf("LoadHelmChartFile() = %q", content)
+	}
+
+	_, err = s.LoadHelmChartFile("repo-a", "README.md")
+	if !IsFileNotFoundError(err) {
+		t.Errorf("LoadHelmChartFile() error = %v, want fileNotFoundError", err)
+	}
+
+	path, content, err := s.LoadCatalogInfoFile("repo-a")
+	if err != nil {
+		t.Fatalf("LoadCatalogInfoFile() unexpected error %v", err)
+	}
+	if path != ".backstage/catalog-info.yaml" || content != "kind: Component\n" {
+		t.Errorf("LoadCatalogInfoFile() = %q, %q", path, content)
+	}
+
+	_, _, err = s.LoadCatalogInfoFile("repo-b")
+	if !IsFileNotFoundError(err) {
+		t.Errorf("LoadCatalogInfoFile() error = %v, want fileNotFoundError", err)
+	}
+
+	path, _, err = s.LoadCodeOwnersFile("repo-a")
+	if err != nil {
+		t.Fatalf("LoadCodeOwnersFile() unexpected error %v", err)
+	}
+	if path != "CODEOWNERS" {
+		t.Errorf("LoadCodeOwnersFile() path = %q, want %q", path, "CODEOWNERS")
+	}
+}
+
+func TestBuildChartFileQuery(t *testing.T) {
+	got := buildChartFileQuery([][]string{{"a", "b"}, {"c"}})
+	want := `query($owner: String!, $n0: String!, $e0_0: String!, $e0_1: String!, $n1: String!, $e1_0: String!) {
+  r0: repository(owner: $owner, name: $n0) {
+    chart0: object(expression: $e0_0) { ... on Blob { text } }
+    chart1: object(expression: $e0_1) { ... on Blob { text } }
+  }
+  r1: repository(owner: $owner, name: $n1) {
+    chart0: object(expression: $e1_0) { ... on Blob { text } }
+  }
+}
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("buildChartFileQuery() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPrefetchContentDetailsFailingBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphqlRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("could not decode request: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if req.Variables["n0"] == "repo-0" {
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"message": "Bad Gateway"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"r0": {}}}`))
+	}))
+	defer server.Close()
+
+	baseURL := server.URL + "/"
+	client, err := github.NewClient(github.WithURLs(&baseURL, &baseURL))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	s := &Service{
+		config:                   Config{GithubOrganization: "giantswarm"},
+		ctx:                      context.Background(),
+		githubClient:             client,
+		graphqlURL:               server.URL + "/graphql",
+		githubRepoContentDetails: make(map[string]GithubRepoContentDetails),
+	}
+
+	names := make([]string, graphqlBatchSize+1)
+	for i := range names {
+		names[i] = fmt.Sprintf("repo-%d", i)
+	}
+
+	err = s.PrefetchContentDetails(names)
+	if err == nil {
+		t.Errorf("PrefetchContentDetails() expected error for failing batch")
+	}
+
+	// The batch after the failing one is still prefetched.
+	var got []string
+	for name := range s.githubRepoContentDetails {
+		got = append(got, name)
+	}
+	if diff := cmp.Diff([]string{fmt.Sprintf("repo-%d", graphqlBatchSize)}, got); diff != "" {
+		t.Errorf("PrefetchContentDetails() cached repositories mismatch (-want +got):\n%s", diff)
+	}
+}
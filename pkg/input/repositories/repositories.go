@@ -6,16 +6,21 @@ package repositories
 import (
 	"context"
 	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/giantswarm/microerror"
 	"github.com/google/go-github/v90/github"
+	"github.com/santhosh-tekuri/jsonschema/v6"
 	"go.yaml.in/yaml/v3"
 
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
 )
 
@@ -32,6 +37,43 @@ type Config struct {
 	// Path within the repository containing repository config YAML lists.
 	// An empty string indicates the root directory.
 	DirectoryPath string
+
+	// Path of a local directory containing repository config YAML lists,
+	// e.g. from a checkout of the repository. If set, lists are read from
+	// this directory instead of the GitHub API.
+	LocalDirectoryPath string
+
+	// Path of a local JSON file with repository details (see RepoSnapshot).
+	// If set, details of the repositories in this file take precedence over
+	// those from the GitHub API.
+	DetailsSnapshotPath string
+
+	// GitHub client to use, e.g. one shared with other services. If nil,
+	// a new client is created using GithubAuthToken. The client must be
+	// authenticated, e.g. as a GitHub App, unless Offline is set.
+	GithubClient *github.Client
+
+	// GitHub instance to use. Defaults to github.com.
+	GithubHost githubhost.Host
+
+	// Path of a local JSON schema file to validate repository lists
+	// against, e.g. from a checkout of the repository. If empty, an
+	// embedded schema is used, unless SchemaRepositoryPath is set.
+	SchemaPath string
+
+	// Path of a JSON schema file within the repository containing our
+	// repositories config, to validate repository lists against. Ignored
+	// if SchemaPath is set or in offline mode.
+	SchemaRepositoryPath string
+
+	// If true, only the repository lists are used, so repository details
+	// are not loaded.
+	ListsOnly bool
+
+	// If true, no requests are made to the GitHub API. Requires
+	// LocalDirectoryPath. Details not found in the snapshot are
+	// considered empty.
+	Offline bool
 }
 
 type ListResult struct {
@@ -42,15 +84,30 @@ type ListResult struct {
 // A service to access information Giant Swarm stores about
 // GitHub repositories, as well as some additional details
 // fetched from the GitHub API.
+//
+// The getter methods are safe for concurrent use.
 type Service struct {
 	config       Config
 	ctx          context.Context
 	githubClient *github.Client
 
-	// Cached information on certain repos
+	// URL of the GitHub GraphQL API, which is not below the REST API URL
+	// on GitHub Enterprise Server.
+	graphqlURL string
+
+	// Schema to validate repository lists against.
+	schema *jsonschema.Schema
+
+	// Cached information on certain repos. Only written in New, so it
+	// can be read concurrently without locking.
 	githubRepoDetails map[string]GithubRepoDetails
-	// Cached information on repo content
+
+	// Names of archived repos. Only written in New.
+	archivedRepos map[string]bool
+
+	// Cached information on repo content, filled lazily and guarded by mu.
 	githubRepoContentDetails map[string]GithubRepoContentDetails
+	mu                       sync.RWMutex
 }
 
 // New instantiates a new repositories service.
@@ -61,37 +118,93 @@ func New(c Config) (*Service, error) {
 	if c.GithubOrganization == "" {
 		return nil, microerror.Maskf(invalidConfigError, "no Github organization configured")
 	}
-	if c.GithubRepositoryName == "" {
+	if c.GithubRepositoryName == "" && c.LocalDirectoryPath == "" {
 		return nil, microerror.Maskf(invalidConfigError, "no Github repository name configured")
 	}
-	if c.GithubAuthToken == "" {
+	if c.Offline && c.LocalDirectoryPath == "" {
+		return nil, microerror.Maskf(invalidConfigError, "offline mode requires a local directory path")
+	}
+	if c.GithubAuthToken == "" && c.GithubClient == nil && !c.Offline {
 		log.Println("WARNING: No Github token given (env variable GITHUB_TOKEN not set)")
 	}
 
 	ctx := context.Background()
-	client, err := httpclient.NewGitHubClient(c.GithubAuthToken)
-	if err != nil {
-		return nil, microerror.Mask(err)
+	client := c.GithubClient
+	if client == nil {
+		var err error
+		client, err = httpclient.NewGitHubClient(c.GithubAuthToken, httpclient.WithHost(c.GithubHost))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
 	}
 
 	s := &Service{
 		config:                   c,
 		ctx:                      ctx,
 		githubClient:             client,
+		graphqlURL:               c.GithubHost.GraphQLURL(),
 		githubRepoDetails:        make(map[string]GithubRepoDetails),
+		archivedRepos:            make(map[string]bool),
 		githubRepoContentDetails: make(map[string]GithubRepoContentDetails),
 	}
 
-	if c.GithubAuthToken != "" {
+	var err error
+	s.schema, err = s.loadSchema()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if c.ListsOnly {
+		return s, nil
+	}
+
+	if (c.GithubAuthToken != "" || c.GithubClient != nil) && !c.Offline {
 		err := s.loadGithubRepoDetails()
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// Snapshot entries take precedence over details loaded from the API.
+	if c.DetailsSnapshotPath != "" {
+		err := s.loadDetailsSnapshot(c.DetailsSnapshotPath)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	return s, nil
 }
 
+// Load repository details, and optionally content details, from a
+// local JSON snapshot file.
+func (s *Service) loadDetailsSnapshot(path string) error {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var snapshot []RepoSnapshot
+	err = json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return microerror.Maskf(invalidConfigError, "could not parse repository details snapshot %s: %v", path, err)
+	}
+
+	for _, repo := range snapshot {
+		s.githubRepoDetails[repo.Name] = repo.GithubRepoDetails
+		if repo.Content != nil {
+			content := *repo.Content
+			if len(content.HelmChartNames) > 0 {
+				content.HasHelmFolder = true
+				content.NumHelmCharts = len(content.HelmChartNames)
+			}
+			s.githubRepoContentDetails[repo.Name] = content
+		}
+	}
+
+	return nil
+}
+
 // Load main information for all repositories of the organization.
 //
 // This also loads info for archived repos, but it's still cheaper
@@ -113,6 +226,7 @@ func (s *Service) loadGithubRepoDetails() error {
 
 		for _, repo := range r {
 			if repo.GetArchived() {
+				s.archivedRepos[repo.GetName()] = true
 				continue
 			}
 
@@ -139,12 +253,38 @@ func (s *Service) loadGithubRepoDetails() error {
 	return nil
 }
 
+// Returns the cached content details for a repository, loading them
+// from the GitHub API on first access.
+func (s *Service) getGithubRepoContentDetails(name string) (GithubRepoContentDetails, error) {
+	s.mu.RLock()
+	details, ok := s.githubRepoContentDetails[name]
+	s.mu.RUnlock()
+	if ok {
+		return details, nil
+	}
+
+	details, err := s.loadGithubRepoContentDetails(name)
+	if err != nil {
+		return GithubRepoContentDetails{}, err
+	}
+
+	s.mu.Lock()
+	s.githubRepoContentDetails[name] = details
+	s.mu.Unlock()
+
+	return details, nil
+}
+
 // Load details found in certain files in the repository.
-func (s *Service) loadGithubRepoContentDetails(name string) error {
+func (s *Service) loadGithubRepoContentDetails(name string) (GithubRepoContentDetails, error) {
 	details := GithubRepoContentDetails{}
 
+	if s.config.Offline {
+		return details, nil
+	}
+
 	// Detect CircleCI
-	circleciFileContent, _, resp, err := s.githubClient.Repositories.GetContents(s.ctx, s.config.GithubOrganization, name, ".circleci/config.yml", nil)
+	circleciFileContent, _, resp, err := s.githubClient.Repositories.GetContents(s.ctx, s.config.GithubOrganization, name, circleciConfigPath, nil)
 	if err == nil {
 		details.HasCircleCI = true
 
@@ -160,20 +300,20 @@ func (s *Service) loadGithubRepoContentDetails(name string) error {
 		}
 	} else if resp.StatusCode != http.StatusNotFound {
 		// 404 is a "not found" error, which is expected. Everything else is not expected.
-		return err
+		return GithubRepoContentDetails{}, err
 	}
 
 	// Detect README
-	_, _, resp, err = s.githubClient.Repositories.GetContents(s.ctx, s.config.GithubOrganization, name, "README.md", nil)
+	_, _, resp, err = s.githubClient.Repositories.GetContents(s.ctx, s.config.GithubOrganization, name, readmePath, nil)
 	if err == nil {
 		details.HasReadme = true
 	} else if resp.StatusCode != http.StatusNotFound {
 		// 404 is a "not found" error, which is expected. Everything else is not expected.
-		return err
+		return GithubRepoContentDetails{}, err
 	}
 
 	// Detect helm folder
-	_, directoryContent, resp, err := s.githubClient.Repositories.GetContents(s.ctx, s.config.GithubOrganization, name, "helm", nil)
+	_, directoryContent, resp, err := s.githubClient.Repositories.GetContents(s.ctx, s.config.GithubOrganization, name, helmPath, nil)
 	if err == nil {
 		if directoryContent != nil {
 			details.HasHelmFolder = true
@@ -185,18 +325,46 @@ func (s *Service) loadGithubRepoContentDetails(name string) error {
 		}
 	} else if resp.StatusCode != http.StatusNotFound {
 		// 404 is a "not found" error, which is expected. Everything else is not expected.
-		return err
+		return GithubRepoContentDetails{}, err
 	}
 
-	s.githubRepoContentDetails[name] = details
+	// Detect GitHub Actions workflows
+	_, directoryContent, resp, err = s.githubClient.Repositories.GetContents(s.ctx, s.config.GithubOrganization, name, githubWorkflowsPath, nil)
+	if err == nil {
+		var workflows []string
+		for _, item := range directoryContent {
+			if item.GetType() != "file" || !isWorkflowFile(item.GetName()) {
+				continue
+			}
+			details.GithubWorkflowNames = append(details.GithubWorkflowNames, item.GetName())
 
-	return nil
+			content, err := s.LoadGitHubFile(name, item.GetPath())
+			if err != nil {
+				log.Printf("WARN - %s - error fetching workflow %s: %v", name, item.GetName(), err)
+				continue
+			}
+			workflows = append(workflows, content)
+		}
+		details.ReusableWorkflows = reusableWorkflows(s.config.GithubOrganization, workflows)
+	} else if resp.StatusCode != http.StatusNotFound {
+		// 404 is a "not found" error, which is expected. Everything else is not expected.
+		return GithubRepoContentDetails{}, err
+	}
+
+	return details, nil
 }
 
 // Return the content of a source file in a repository as string.
 func (s *Service) LoadGitHubFile(name string, path string) (string, error) {
-	fileContent, _, _, err := s.githubClient.Repositories.GetContents(s.ctx, s.config.GithubOrganization, name, path, nil)
+	if s.config.Offline {
+		return "", microerror.Maskf(fileNotFoundError, "file %s of repository %s not available in offline mode", path, name)
+	}
+
+	fileContent, _, resp, err := s.githubClient.Repositories.GetContents(s.ctx, s.config.GithubOrganization, name, path, nil)
 	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return "", microerror.Maskf(fileNotFoundError, "file %s not found in repository %s", path, name)
+		}
 		return "", err
 	}
 
@@ -207,6 +375,79 @@ func (s *Service) LoadGitHubFile(name string, path string) (string, error) {
 	return fileContent.GetContent()
 }
 
+// Returns the content of the Chart.yaml file of the given Helm chart
+// in the repo. Uses the prefetched content if available, otherwise
+// fetches the file from GitHub.
+func (s *Service) LoadHelmChartFile(name, chartName string) (string, error) {
+	details, err := s.getGithubRepoContentDetails(name)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	path := fmt.Sprintf("%s/%s/Chart.yaml", helmPath, chartName)
+
+	if details.HelmChartFiles == nil {
+		return s.LoadGitHubFile(name, path)
+	}
+
+	content, ok := details.HelmChartFiles[chartName]
+	if !ok {
+		return "", microerror.Maskf(fileNotFoundError, "file %s not found in repository %s", path, name)
+	}
+
+	return content, nil
+}
+
+// Returns the path and content of the hand-maintained catalog-info file
+// in the repo. Uses the prefetched content if available, otherwise
+// fetches the file from GitHub. Returns fileNotFoundError if the repo
+// has no such file.
+func (s *Service) LoadCatalogInfoFile(name string) (string, string, error) {
+	details, err := s.getGithubRepoContentDetails(name)
+	if err != nil {
+		return "", "", microerror.Mask(err)
+	}
+
+	return s.loadFirstFile(name, catalogInfoPaths, details.CatalogInfoFiles)
+}
+
+// Returns the path and content of the CODEOWNERS file in the repo, the
+// same way GitHub looks it up. Uses the prefetched content if available,
+// otherwise fetches the file from GitHub. Returns fileNotFoundError if the
+// repo has no such file.
+func (s *Service) LoadCodeOwnersFile(name string) (string, string, error) {
+	details, err := s.getGithubRepoContentDetails(name)
+	if err != nil {
+		return "", "", microerror.Mask(err)
+	}
+
+	return s.loadFirstFile(name, codeOwnersPaths, details.CodeOwnersFiles)
+}
+
+// Returns the path and content of the first of the given files that exists
+// in the repo. If prefetched is nil, the files are fetched from GitHub.
+func (s *Service) loadFirstFile(name string, paths []string, prefetched map[string]string) (string, string, error) {
+	for _, path := range paths {
+		if prefetched != nil {
+			if content, ok := prefetched[path]; ok {
+				return path, content, nil
+			}
+			continue
+		}
+
+		content, err := s.LoadGitHubFile(name, path)
+		if IsFileNotFoundError(err) {
+			continue
+		} else if err != nil {
+			return "", "", microerror.Mask(err)
+		}
+
+		return path, content, nil
+	}
+
+	return "", "", microerror.Maskf(fileNotFoundError, "none of %s found in repository %s", strings.Join(paths, ", "), name)
+}
+
 // Loads a list of repository configurations from a local path.
 // The file name is asserted in the format `<team_name>.yaml`, with all
 // repositories mentioned in it belonging to the team of that name.
@@ -217,28 +458,65 @@ func (s *Service) loadList(path string) ([]Repo, error) {
 		return nil, err
 	}
 
-	return s.loadListFromBytes(data)
+	repos, problems := parseList(filepath.Base(path), data, s.schema)
+	if len(problems) > 0 {
+		return nil, listErrors(problems)
+	}
+
+	return repos, nil
 }
 
-func (s *Service) loadListFromBytes(data []byte) ([]Repo, error) {
-	repos := []Repo{}
-	err := yaml.Unmarshal(data, &repos)
+// GetLists loads the lists of repository YAML files from GitHub giantswarm/github,
+// or from the local directory if configured.
+//
+// Lists are validated against the schema. If any problems are found, an
+// invalidListError describing all of them is returned.
+func (s *Service) GetLists() ([]ListResult, error) {
+	result, problems, err := s.getLists()
 	if err != nil {
 		return nil, err
 	}
+	if len(problems) > 0 {
+		return nil, listErrors(problems)
+	}
 
-	return repos, nil
+	return result, nil
 }
 
-// GetLists loads the lists of repository YAML files from GitHub giantswarm/github.
-func (s *Service) GetLists() ([]ListResult, error) {
+// ValidateLists loads and validates all repository lists, and returns the
+// problems found, sorted by file and line. An error is returned only if
+// lists can't be loaded at all.
+func (s *Service) ValidateLists() ([]ListError, error) {
+	_, problems, err := s.getLists()
+	if err != nil {
+		return nil, err
+	}
+
+	return problems, nil
+}
+
+// listErrors returns an invalidListError describing all problems.
+func listErrors(problems []ListError) error {
+	lines := make([]string, len(problems))
+	for i, p := range problems {
+		lines[i] = p.Error()
+	}
+	return microerror.Maskf(invalidListError, "%d problem(s) found in repository lists:\n%s", len(problems), strings.Join(lines, "\n"))
+}
+
+func (s *Service) getLists() ([]ListResult, []ListError, error) {
+	if s.config.LocalDirectoryPath != "" {
+		return s.getLocalLists()
+	}
+
 	// Get repositories directory content.
 	_, directoryContent, _, err := s.githubClient.Repositories.GetContents(s.ctx, s.config.GithubOrganization, s.config.GithubRepositoryName, s.config.DirectoryPath, nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	result := []ListResult{}
+	var problems []ListError
 
 	for _, item := range directoryContent {
 		if !strings.HasSuffix(*item.Name, ".yaml") {
@@ -248,22 +526,57 @@ func (s *Service) GetLists() ([]ListResult, error) {
 		// Get individual team repositories file.
 		fileContent, _, _, err := s.githubClient.Repositories.GetContents(s.ctx, s.config.GithubOrganization, s.config.GithubRepositoryName, *item.Path, nil)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		decodedContent, _ := b64.StdEncoding.DecodeString(*fileContent.Content)
-		lists, err := s.loadListFromBytes(decodedContent)
+		repos, listProblems := parseList(*item.Name, decodedContent, s.schema)
+		problems = append(problems, listProblems...)
+
+		result = append(result, ListResult{
+			OwnerTeamName: strings.TrimSuffix(*item.Name, ".yaml"),
+			Repositories:  repos,
+		})
+	}
+
+	sortListErrors(problems)
+
+	return result, problems, nil
+}
+
+// Loads the lists of repository YAML files from the local directory.
+// Files are processed in alphabetical order.
+func (s *Service) getLocalLists() ([]ListResult, []ListError, error) {
+	entries, err := os.ReadDir(s.config.LocalDirectoryPath)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+
+	result := []ListResult{}
+	var problems []ListError
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".yaml") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.config.LocalDirectoryPath, entry.Name()))
 		if err != nil {
-			return nil, err
+			return nil, nil, microerror.Maskf(invalidConfigError, "could not load list %s: %v", entry.Name(), err)
 		}
 
+		repos, listProblems := parseList(entry.Name(), data, s.schema)
+		problems = append(problems, listProblems...)
+
 		result = append(result, ListResult{
-			OwnerTeamName: strings.TrimSuffix(*item.Name, ".yaml"),
-			Repositories:  lists,
+			OwnerTeamName: strings.TrimSuffix(entry.Name(), ".yaml"),
+			Repositories:  repos,
 		})
 	}
 
-	return result, nil
+	sortListErrors(problems)
+
+	return result, problems, nil
 }
 
 // Returns the description for the given repo. If not available,
@@ -286,9 +599,13 @@ func (s *Service) MustGetLanguage(name string) string {
 	return s.githubRepoDetails[name].MainLanguage
 }
 
-// Returns the public/private info for the given repo.
+// Returns the public/private info for the given repo. In offline mode,
+// repos missing from the snapshot are considered public.
 func (s *Service) GetIsPrivate(name string) (bool, error) {
 	if _, ok := s.githubRepoDetails[name]; !ok {
+		if s.config.Offline {
+			return false, nil
+		}
 		return false, microerror.Maskf(repositoryNotFoundError, "repository %s not found", name)
 	}
 
@@ -306,63 +623,73 @@ func (s *Service) MustGetDefaultBranch(name string) string {
 
 // Returns whether the repo has a CircleCI configuration.
 func (s *Service) GetHasCircleCI(name string) (bool, error) {
-	if _, ok := s.githubRepoContentDetails[name]; !ok {
-		err := s.loadGithubRepoContentDetails(name)
-		if err != nil {
-			return false, microerror.Mask(err)
-		}
+	details, err := s.getGithubRepoContentDetails(name)
+	if err != nil {
+		return false, microerror.Mask(err)
 	}
 
-	return s.githubRepoContentDetails[name].HasCircleCI, nil
+	return details.HasCircleCI, nil
 }
 
 // Returns whether the repo's CircleCI config uses force-public in push-to-registries,
 // meaning charts/images go to the public registry despite the repo being private.
 func (s *Service) GetForcePublicRegistry(name string) (bool, error) {
-	if _, ok := s.githubRepoContentDetails[name]; !ok {
-		err := s.loadGithubRepoContentDetails(name)
-		if err != nil {
-			return false, microerror.Mask(err)
-		}
+	details, err := s.getGithubRepoContentDetails(name)
+	if err != nil {
+		return false, microerror.Mask(err)
 	}
 
-	return s.githubRepoContentDetails[name].ForcePublicRegistry, nil
+	return details.ForcePublicRegistry, nil
 }
 
 // Returns whether the repo has a main README file.
 func (s *Service) GetHasReadme(name string) (bool, error) {
-	if _, ok := s.githubRepoContentDetails[name]; !ok {
-		err := s.loadGithubRepoContentDetails(name)
-		if err != nil {
-			return false, microerror.Mask(err)
-		}
+	details, err := s.getGithubRepoContentDetails(name)
+	if err != nil {
+		return false, microerror.Mask(err)
 	}
 
-	return s.githubRepoContentDetails[name].HasReadme, nil
+	return details.HasReadme, nil
 }
 
 // Returns whether the repo has a Helm chart.
 func (s *Service) GetNumHelmCharts(name string) (int, error) {
-	if _, ok := s.githubRepoContentDetails[name]; !ok {
-		err := s.loadGithubRepoContentDetails(name)
-		if err != nil {
-			return 0, microerror.Mask(err)
-		}
+	details, err := s.getGithubRepoContentDetails(name)
+	if err != nil {
+		return 0, microerror.Mask(err)
 	}
 
-	return s.githubRepoContentDetails[name].NumHelmCharts, nil
+	return details.NumHelmCharts, nil
+}
+
+// Returns the file names of the repo's GitHub Actions workflows.
+func (s *Service) GetGithubWorkflowNames(name string) ([]string, error) {
+	details, err := s.getGithubRepoContentDetails(name)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return details.GithubWorkflowNames, nil
+}
+
+// Returns the file names of the reusable workflows the repo's workflows call.
+func (s *Service) GetReusableWorkflows(name string) ([]string, error) {
+	details, err := s.getGithubRepoContentDetails(name)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return details.ReusableWorkflows, nil
 }
 
 // Returns the name(s) of the repo's Helm chart(s).
 func (s *Service) GetHelmChartNames(name string) ([]string, error) {
-	if _, ok := s.githubRepoContentDetails[name]; !ok {
-		err := s.loadGithubRepoContentDetails(name)
-		if err != nil {
-			return nil, microerror.Mask(err)
-		}
+	details, err := s.getGithubRepoContentDetails(name)
+	if err != nil {
+		return nil, microerror.Mask(err)
 	}
 
-	return s.githubRepoContentDetails[name].HelmChartNames, nil
+	return details.HelmChartNames, nil
 }
 
 // circleciConfigHasForcePublic parses a CircleCI config YAML and checks whether
@@ -4,10 +4,19 @@
 package repositories
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v90/github"
 )
 
 func TestCircleciConfigHasForcePublic(t *testing.T) {
@@ -270,3 +279,205 @@ func TestLoadList(t *testing.T) {
 		})
 	}
 }
+
+// TestContentDetailsConcurrentAccess loads the content details of uncached
+// repositories from several goroutines at once, so that -race covers both
+// reading and filling the cache.
+func TestContentDetailsConcurrentAccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		repo, path, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/repos/giantswarm/"), "/contents/")
+		switch path {
+		case readmePath:
+			_, _ = w.Write([]byte(`{"type": "file", "name": "README.md", "path": "README.md"}`))
+		case helmPath:
+			_, _ = fmt.Fprintf(w, `[{"type": "dir", "name": "chart-%s", "path": "helm/chart-%s"}]`, repo, repo)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	baseURL := server.URL + "/"
+	client, err := github.NewClient(github.WithURLs(&baseURL, &baseURL))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	repos := []string{"repo-a", "repo-b", "repo-c", "repo-d"}
+	s := &Service{
+		config:                   Config{GithubOrganization: "giantswarm"},
+		ctx:                      context.Background(),
+		githubClient:             client,
+		githubRepoDetails:        map[string]GithubRepoDetails{},
+		githubRepoContentDetails: map[string]GithubRepoContentDetails{},
+	}
+	for _, repo := range repos {
+		s.githubRepoDetails[repo] = GithubRepoDetails{Name: repo, IsPrivate: true}
+	}
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		repo := repos[i%len(repos)]
+		wg.Go(func() {
+			if got, err := s.GetHasReadme(repo); err != nil || !got {
+				t.Errorf("GetHasReadme(%s) = %v, %v, want true, nil", repo, got, err)
+			}
+			if got, err := s.GetHelmChartNames(repo); err != nil || !cmp.Equal(got, []string{"chart-" + repo}) {
+				t.Errorf("GetHelmChartNames(%s) = %v, %v, want [chart-%s], nil", repo, got, err, repo)
+			}
+			if got, err := s.GetIsPrivate(repo); err != nil || !got {
+				t.Errorf("GetIsPrivate(%s) = %v, %v, want true, nil", repo, got, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if len(s.githubRepoContentDetails) != len(repos) {
+		t.Errorf("cached content details of %d repositories, want %d", len(s.githubRepoContentDetails), len(repos))
+	}
+}
+
+func TestGetListsLocal(t *testing.T) {
+	s, err := New(Config{
+		GithubOrganization: "giantswarm",
+		LocalDirectoryPath: "testdata",
+		Offline:            true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	lists, err := s.GetLists()
+	if err != nil {
+		t.Fatalf("GetLists() unexpected error %v", err)
+	}
+
+	var got []string
+	for _, list := range lists {
+		got = append(got, list.OwnerTeamName)
+	}
+	want := []string{"artificial", "team-atlas", "team-bigmac", "team-cabbage", "team-clippy", "team-honeybadger"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetLists() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Repos unknown in offline mode are considered public and empty.
+	isPrivate, err := s.GetIsPrivate("name-only")
+	if err != nil || isPrivate {
+		t.Errorf("GetIsPrivate() = %v, %v, want false, nil", isPrivate, err)
+	}
+	hasReadme, err := s.GetHasReadme("name-only")
+	if err != nil || hasReadme {
+		t.Errorf("GetHasReadme() = %v, %v, want false, nil", hasReadme, err)
+	}
+}
+
+func TestLoadGitHubFileNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+	}))
+	defer server.Close()
+
+	baseURL := server.URL + "/"
+	client, err := github.NewClient(github.WithURLs(&baseURL, &baseURL))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	s := &Service{
+		config:       Config{GithubOrganization: "giantswarm"},
+		ctx:          context.Background(),
+		githubClient: client,
+	}
+
+	_, err = s.LoadGitHubFile("repo-a", "go.mod")
+	if !IsFileNotFoundError(err) {
+		t.Errorf("LoadGitHubFile() error = %v, want fileNotFoundError", err)
+	}
+}
+
+func TestDetailsSnapshotOverAPI(t *testing.T) {
+	var probed []any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/graphql" {
+			var req graphqlRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("could not decode request: %v", err)
+			}
+			for i := range len(req.Variables) - 1 {
+				probed = append(probed, req.Variables[fmt.Sprintf("n%d", i)])
+			}
+			_, _ = w.Write([]byte(`{"data": {"r0": {}}}`))
+			return
+		}
+		if r.URL.Path != "/orgs/giantswarm/repos" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"name": "repo-a", "description": "From API", "private": true, "default_branch": "main"},
+			{"name": "repo-b", "description": "From API", "private": true, "default_branch": "main"}
+		]`))
+	}))
+	defer server.Close()
+
+	baseURL := server.URL + "/"
+	client, err := github.NewClient(github.WithURLs(&baseURL, &baseURL))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	snapshot := filepath.Join(t.TempDir(), "details.json")
+	err = os.WriteFile(snapshot, []byte(`[{"name": "repo-b", "description": "From snapshot", "isPrivate": false, "defaultBranch": "master", "content": {"hasReadme": true}}]`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(Config{
+		GithubOrganization:  "giantswarm",
+		LocalDirectoryPath:  "testdata",
+		GithubClient:        client,
+		DetailsSnapshotPath: snapshot,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// Repos missing in the snapshot keep their details from the API.
+	if got := s.MustGetDescription("repo-a"); got != "From API" {
+		t.Errorf("MustGetDescription(repo-a) = %q, want %q", got, "From API")
+	}
+	isPrivate, err := s.GetIsPrivate("repo-a")
+	if err != nil || !isPrivate {
+		t.Errorf("GetIsPrivate(repo-a) = %v, %v, want true, nil", isPrivate, err)
+	}
+
+	if got := s.MustGetDescription("repo-b"); got != "From snapshot" {
+		t.Errorf("MustGetDescription(repo-b) = %q, want %q", got, "From snapshot")
+	}
+	isPrivate, err = s.GetIsPrivate("repo-b")
+	if err != nil || isPrivate {
+		t.Errorf("GetIsPrivate(repo-b) = %v, %v, want false, nil", isPrivate, err)
+	}
+	if got := s.MustGetDefaultBranch("repo-b"); got != "master" {
+		t.Errorf("MustGetDefaultBranch(repo-b) = %q, want %q", got, "master")
+	}
+
+	// Content details from the snapshot aren't replaced by prefetched ones.
+	s.graphqlURL = server.URL + "/graphql"
+	err = s.PrefetchContentDetails([]string{"repo-a", "repo-b"})
+	if err != nil {
+		t.Fatalf("PrefetchContentDetails() unexpected error %v", err)
+	}
+	if diff := cmp.Diff([]any{"repo-a"}, probed); diff != "" {
+		t.Errorf("PrefetchContentDetails() probed repositories mismatch (-want +got):\n%s", diff)
+	}
+	hasReadme, err := s.GetHasReadme("repo-b")
+	if err != nil || !hasReadme {
+		t.Errorf("GetHasReadme(repo-b) = %v, %v, want true, nil", hasReadme, err)
+	}
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+// Reasons for a repository list entry to be stale.
+const (
+	StaleReasonMissing  = "missing"
+	StaleReasonArchived = "archived"
+	StaleReasonRenamed  = "renamed"
+)
+
+// StaleRepo describes a repository list entry that doesn't refer to an
+// active repository of the organization on GitHub.
+type StaleRepo struct {
+	// Name of the repository as given in the list.
+	Name string
+
+	// One of the StaleReason* constants.
+	Reason string
+
+	// Current name of a renamed repository, as full name including the
+	// owner if the repository was transferred to another owner.
+	NewName string
+}
+
+func (r StaleRepo) String() string {
+	switch {
+	case r.Reason == StaleReasonRenamed:
+		return fmt.Sprintf("%s: renamed to %s", r.Name, r.NewName)
+	case r.NewName != "":
+		return fmt.Sprintf("%s: %s, renamed to %s", r.Name, r.Reason, r.NewName)
+	default:
+		return fmt.Sprintf("%s: %s", r.Name, r.Reason)
+	}
+}
+
+// CheckRepo returns nil if the named repository exists and is not archived,
+// otherwise a description of why the list entry is stale. Repositories not
+// found in the details loaded in New are looked up via the GitHub API,
+// following rename redirects.
+//
+// In offline mode, every repository is considered active.
+func (s *Service) CheckRepo(name string) (*StaleRepo, error) {
+	if s.config.Offline {
+		return nil, nil
+	}
+	if _, ok := s.githubRepoDetails[name]; ok {
+		return nil, nil
+	}
+	if s.archivedRepos[name] {
+		return &StaleRepo{Name: name, Reason: StaleReasonArchived}, nil
+	}
+
+	repo, resp, err := s.githubClient.Repositories.Get(s.ctx, s.config.GithubOrganization, name)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return &StaleRepo{Name: name, Reason: StaleReasonMissing}, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// GitHub redirects requests for renamed repositories to the new one.
+	newName := repo.GetName()
+	if owner := repo.GetOwner().GetLogin(); !strings.EqualFold(owner, s.config.GithubOrganization) {
+		newName = repo.GetFullName()
+	}
+	if newName == name {
+		newName = ""
+	}
+
+	switch {
+	case repo.GetArchived():
+		return &StaleRepo{Name: name, Reason: StaleReasonArchived, NewName: newName}, nil
+	case newName != "":
+		return &StaleRepo{Name: name, Reason: StaleReasonRenamed, NewName: newName}, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v90/github"
+)
+
+func TestCheckRepo(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/giantswarm/old-name", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/repositories/42", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/repositories/42", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name": "new-name", "full_name": "giantswarm/new-name", "owner": {"login": "giantswarm"}}`))
+	})
+	mux.HandleFunc("/repos/giantswarm/transferred", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name": "transferred", "full_name": "example/transferred", "owner": {"login": "example"}}`))
+	})
+	mux.HandleFunc("/repos/giantswarm/archived-renamed", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name": "archived-new", "full_name": "giantswarm/archived-new", "owner": {"login": "giantswarm"}, "archived": true}`))
+	})
+	mux.HandleFunc("/repos/giantswarm/unlisted", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name": "unlisted", "full_name": "giantswarm/unlisted", "owner": {"login": "giantswarm"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	baseURL := server.URL + "/"
+	client, err := github.NewClient(github.WithURLs(&baseURL, &baseURL))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	s := &Service{
+		config:            Config{GithubOrganization: "giantswarm"},
+		ctx:               context.Background(),
+		githubClient:      client,
+		githubRepoDetails: map[string]GithubRepoDetails{"active": {Name: "active"}},
+		archivedRepos:     map[string]bool{"archived": true},
+	}
+
+	tests := []struct {
+		name string
+		want *StaleRepo
+	}{
+		{name: "active"},
+		{name: "unlisted"},
+		{name: "archived", want: &StaleRepo{Name: "archived", Reason: StaleReasonArchived}},
+		{name: "deleted", want: &StaleRepo{Name: "deleted", Reason: StaleReasonMissing}},
+		{name: "old-name", want: &StaleRepo{Name: "old-name", Reason: StaleReasonRenamed, NewName: "new-name"}},
+		{name: "transferred", want: &StaleRepo{Name: "transferred", Reason: StaleReasonRenamed, NewName: "example/transferred"}},
+		{name: "archived-renamed", want: &StaleRepo{Name: "archived-renamed", Reason: StaleReasonArchived, NewName: "archived-new"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.CheckRepo(tt.name)
+			if err != nil {
+				t.Fatalf("CheckRepo() unexpected error %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("CheckRepo() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	s.config.Offline = true
+	if got, err := s.CheckRepo("deleted"); got != nil || err != nil {
+		t.Errorf("CheckRepo() in offline mode = %v, %v, want nil, nil", got, err)
+	}
+}
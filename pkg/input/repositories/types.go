@@ -1,15 +1,19 @@
 package repositories
 
 // Repo represents an entry in the giantswarm/github repositories YAML data.
+//
+// Lists are decoded strictly, so every key allowed by the schema must have
+// a field here.
 type Repo struct {
-	Name          string             `yaml:"name"`
-	ComponentType string             `yaml:"componentType"`
-	System        string             `yaml:"system"`
-	Gen           RepoGen            `yaml:"gen"`
-	Lifecycle     RepoLifecycle      `yaml:"lifecycle"`
-	Replacements  RepoReplacements   `yaml:"replace"`
-	AppTestSuite  interface{}        `yaml:"app_test_suite"`
-	UpstreamCheck *RepoUpstreamCheck `yaml:"upstreamCheck"`
+	Name            string             `yaml:"name"`
+	ComponentType   string             `yaml:"componentType"`
+	System          string             `yaml:"system"`
+	DeploymentNames []string           `yaml:"deploymentNames"`
+	Gen             RepoGen            `yaml:"gen"`
+	Lifecycle       RepoLifecycle      `yaml:"lifecycle"`
+	Replacements    RepoReplacements   `yaml:"replace"`
+	AppTestSuite    RepoAppTestSuite   `yaml:"app_test_suite"`
+	UpstreamCheck   *RepoUpstreamCheck `yaml:"upstreamCheck"`
 }
 
 // HasUpstreamCheck reports whether the repo opted into the monthly upstream
@@ -71,6 +75,10 @@ type RepoUpstreamCheck struct {
 	ReleasePrefix string `yaml:"releasePrefix"`
 }
 
+// RepoAppTestSuite holds the app_test_suite block configuring tests with
+// app-test-suite. Its settings are not evaluated here.
+type RepoAppTestSuite map[string]any
+
 type RepoReplacements struct {
 	ArchitectOrb bool `yaml:"architect-orb"`
 	Renovate     bool `yaml:"renovate"`
@@ -81,38 +89,65 @@ type RepoReplacements struct {
 // repository details we need.
 type GithubRepoDetails struct {
 	// Repository name
-	Name string
+	Name string `json:"name"`
 
 	// Repository description
-	Description string
+	Description string `json:"description,omitempty"`
 
 	// Name of the default branch
-	DefaultBranch string
+	DefaultBranch string `json:"defaultBranch,omitempty"`
 
 	// Whether the repository is private. If false, it's public.
-	IsPrivate bool
+	IsPrivate bool `json:"isPrivate,omitempty"`
 
 	// The main programming language in the repo.
-	MainLanguage string
+	MainLanguage string `json:"mainLanguage,omitempty"`
 }
 
 // A struct for caching repository content information.
 type GithubRepoContentDetails struct {
 	// Whether the repository has a CircleCI configuration file.
-	HasCircleCI bool
+	HasCircleCI bool `json:"hasCircleCI,omitempty"`
 
 	// Whether the CircleCI config uses force-public in push-to-registries,
 	// meaning charts/images are published to the public registry even though
 	// the repository is private.
-	ForcePublicRegistry bool
+	ForcePublicRegistry bool `json:"forcePublicRegistry,omitempty"`
 
 	// Whether the repository has a README.md in the root directory.
-	HasReadme bool
+	HasReadme bool `json:"hasReadme,omitempty"`
 
 	// Whether the repository has a "helm" folder in the root directory.
-	HasHelmFolder bool
+	HasHelmFolder bool `json:"hasHelmFolder,omitempty"`
+
+	NumHelmCharts int `json:"numHelmCharts,omitempty"`
+
+	HelmChartNames []string `json:"helmChartNames,omitempty"`
+
+	// Content of the Chart.yaml file per Helm chart name, if prefetched.
+	// Nil if the details were loaded via the REST API.
+	HelmChartFiles map[string]string `json:"helmChartFiles,omitempty"`
+
+	// Names of the GitHub Actions workflow files in .github/workflows.
+	GithubWorkflowNames []string `json:"githubWorkflowNames,omitempty"`
+
+	// File names of the reusable workflows from giantswarm/github-workflows
+	// called by the repository's workflows.
+	ReusableWorkflows []string `json:"reusableWorkflows,omitempty"`
+
+	// Content of the catalog-info files found, keyed by path. Nil if the
+	// files have not been probed yet.
+	CatalogInfoFiles map[string]string `json:"catalogInfoFiles,omitempty"`
+
+	// Content of the CODEOWNERS files found, keyed by path. Nil if the
+	// files have not been probed yet.
+	CodeOwnersFiles map[string]string `json:"codeOwnersFiles,omitempty"`
+}
 
-	NumHelmCharts int
+// RepoSnapshot is an entry in a repository details snapshot file, which
+// is a JSON array of these entries. Content details are optional.
+type RepoSnapshot struct {
+	GithubRepoDetails
 
-	HelmChartNames []string
+	Content *GithubRepoContentDetails `json:"content,omitempty"`
 }
@@ -0,0 +1,255 @@
+package repositories
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"go.yaml.in/yaml/v3"
+)
+
+// Schema the repository lists are validated against, unless configured
+// otherwise. It mirrors the parts of giantswarm/github's
+// repositories.schema.json that we know about.
+//
+//go:embed repositories.schema.json
+var defaultSchema []byte
+
+const schemaURL = "repositories.schema.json"
+
+// ListError describes a problem found in a repository list file.
+type ListError struct {
+	// Name of the list file.
+	File string
+
+	// Line number of the problem, starting at 1. Zero if unknown.
+	Line int
+
+	// Name of the repository entry the problem was found in, if known.
+	Repo string
+
+	// Description of the problem.
+	Message string
+}
+
+func (e ListError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d", e.Line)
+	}
+	if e.Repo != "" {
+		fmt.Fprintf(&b, ": repository %q", e.Repo)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// loadSchema returns the configured schema to validate repository lists
+// against, read from a local file, from the GitHub repository, or the
+// embedded default schema.
+func (s *Service) loadSchema() (*jsonschema.Schema, error) {
+	switch {
+	case s.config.SchemaPath != "":
+		data, err := os.ReadFile(filepath.Clean(s.config.SchemaPath))
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "could not read repositories schema: %v", err)
+		}
+		return compileSchema(data)
+	case s.config.SchemaRepositoryPath != "" && !s.config.Offline:
+		content, err := s.LoadGitHubFile(s.config.GithubRepositoryName, s.config.SchemaRepositoryPath)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "could not load repositories schema: %v", err)
+		}
+		return compileSchema([]byte(content))
+	default:
+		return compileSchema(defaultSchema)
+	}
+}
+
+// compileSchema compiles the given JSON schema document.
+func compileSchema(data []byte) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "could not parse repositories schema: %v", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	err = compiler.AddResource(schemaURL, doc)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "could not load repositories schema: %v", err)
+	}
+	schema, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "could not compile repositories schema: %v", err)
+	}
+
+	return schema, nil
+}
+
+// parseList validates the content of a list file against the schema and
+// decodes it strictly, rejecting keys unknown to Repo. All problems found
+// are returned.
+func parseList(file string, data []byte, schema *jsonschema.Schema) ([]Repo, []ListError) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, []ListError{yamlListError(file, err)}
+	}
+	if root.Kind == 0 {
+		// Empty file
+		return []Repo{}, nil
+	}
+
+	var problems []ListError
+
+	var instance any
+	if err := root.Decode(&instance); err != nil {
+		return nil, []ListError{yamlListError(file, err)}
+	}
+	err := schema.Validate(instance)
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		for _, leaf := range leafErrors(validationErr) {
+			node := nodeAt(&root, leaf.InstanceLocation)
+			problem := ListError{
+				File:    file,
+				Repo:    repoNameAt(&root, leaf.InstanceLocation),
+				Message: schemaErrorMessage(leaf),
+			}
+			if node != nil {
+				problem.Line = node.Line
+			}
+			problems = append(problems, problem)
+		}
+	} else if err != nil {
+		problems = append(problems, ListError{File: file, Message: err.Error()})
+	}
+
+	repos := []Repo{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&repos); err != nil && len(problems) == 0 {
+		// Schema violations usually explain decoding errors better.
+		problems = append(problems, yamlListError(file, err))
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	return repos, nil
+}
+
+// yamlListError converts a YAML error into ListErrors, extracting the line
+// number from messages like "yaml: line 3: ...".
+func yamlListError(file string, err error) ListError {
+	message := err.Error()
+	message = strings.TrimPrefix(message, "yaml: unmarshal errors:\n")
+	message = strings.TrimSpace(strings.TrimPrefix(message, "yaml: "))
+
+	problem := ListError{File: file, Message: message}
+	if rest, ok := strings.CutPrefix(message, "line "); ok {
+		number, text, found := strings.Cut(rest, ": ")
+		if line, err := strconv.Atoi(number); found && err == nil {
+			problem.Line = line
+			problem.Message = text
+		}
+	}
+	return problem
+}
+
+// leafErrors returns the most specific validation errors, which describe
+// the actual problems.
+func leafErrors(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+	var leaves []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		leaves = append(leaves, leafErrors(cause)...)
+	}
+	return leaves
+}
+
+// schemaErrorMessage describes a validation error, prefixed with the path
+// of the offending value within the repository entry.
+func schemaErrorMessage(err *jsonschema.ValidationError) string {
+	message := err.Error()
+	if unit := err.BasicOutput(); unit != nil && unit.Error != nil {
+		message = unit.Error.String()
+	}
+
+	// The first element is the index of the entry in the list.
+	if len(err.InstanceLocation) > 1 {
+		return strings.Join(err.InstanceLocation[1:], ".") + ": " + message
+	}
+	return message
+}
+
+// nodeAt returns the YAML node at the given location, or the closest
+// ancestor found.
+func nodeAt(root *yaml.Node, location []string) *yaml.Node {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	for _, segment := range location {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(node.Content) {
+				next = node.Content[i]
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == segment {
+					next = node.Content[i+1]
+					break
+				}
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+
+	return node
+}
+
+// repoNameAt returns the name of the repository entry containing the given
+// location, if any.
+func repoNameAt(root *yaml.Node, location []string) string {
+	if len(location) == 0 {
+		return ""
+	}
+	entry := nodeAt(root, location[:1])
+	if entry == nil || entry.Kind != yaml.MappingNode {
+		return ""
+	}
+	name := nodeAt(entry, []string{"name"})
+	if name == entry || name.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return name.Value
+}
+
+// sortListErrors sorts problems by file and line.
+func sortListErrors(problems []ListError) {
+	slices.SortStableFunc(problems, func(a, b ListError) int {
+		if c := strings.Compare(a.File, b.File); c != 0 {
+			return c
+		}
+		return a.Line - b.Line
+	})
+}
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseList(t *testing.T) {
+	schema, err := compileSchema(defaultSchema)
+	if err != nil {
+		t.Fatalf("compileSchema() unexpected error %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		input        string
+		wantRepos    []Repo
+		wantProblems []ListError
+	}{
+		{
+			name: "Valid",
+			input: `- name: app-operator
+  componentType: service
+  deploymentNames: [app-operator]
+  gen:
+    flavours: [app]
+    language: go
+  app_test_suite:
+    smoke: true
+`,
+			wantRepos: []Repo{
+				{
+					Name:            "app-operator",
+					ComponentType:   "service",
+					DeploymentNames: []string{"app-operator"},
+					Gen:             RepoGen{Flavors: []RepoFlavor{RepoFlavorApp}, Language: RepoLanguageGo},
+					AppTestSuite:    RepoAppTestSuite{"smoke": true},
+				},
+			},
+		},
+		{
+			name:      "Empty",
+			input:     ``,
+			wantRepos: []Repo{},
+		},
+		{
+			name: "UnknownKey",
+			input: `- name: app-operator
+- name: dashboards
+  gen:
+    language: go
+    flavors: [app]
+`,
+			wantProblems: []ListError{
+				{File: "team.yaml", Line: 4, Repo: "dashboards", Message: "gen: additional properties 'flavors' not allowed"},
+			},
+		},
+		{
+			name: "InvalidValues",
+			input: `- name: app-operator
+  lifecycle: prod
+  gen:
+    language: rust
+- componentType: service
+`,
+			wantProblems: []ListError{
+				{File: "team.yaml", Line: 2, Repo: "app-operator", Message: "lifecycle: value must be one of 'experimental', 'production', 'deprecated'"},
+				{File: "team.yaml", Line: 4, Repo: "app-operator", Message: "gen.language: value must be one of 'generic', 'go', 'python'"},
+				{File: "team.yaml", Line: 5, Message: "missing property 'name'"},
+			},
+		},
+		{
+			name:  "InvalidYAML",
+			input: "- name: app-operator\n  gen: [\n",
+			wantProblems: []ListError{
+				{File: "team.yaml", Line: 2, Message: "did not find expected node content"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos, problems := parseList("team.yaml", []byte(tt.input), schema)
+			sortListErrors(problems)
+
+			if diff := cmp.Diff(tt.wantProblems, problems); diff != "" {
+				t.Errorf("parseList() problems mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantRepos, repos); diff != "" {
+				t.Errorf("parseList() repos mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateListsCustomSchema(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "schema.json")
+	err := os.WriteFile(schemaPath, []byte(`{"type": "array", "items": {"type": "object", "required": ["name", "system"]}}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(Config{
+		GithubOrganization: "giantswarm",
+		LocalDirectoryPath: "testdata",
+		SchemaPath:         schemaPath,
+		ListsOnly:          true,
+		Offline:            true,
+	})
+	if err != nil {
+		t.Fatalf("New() unexpected error %v", err)
+	}
+
+	problems, err := s.ValidateLists()
+	if err != nil {
+		t.Fatalf("ValidateLists() unexpected error %v", err)
+	}
+	if len(problems) == 0 {
+		t.Fatal("ValidateLists() found no problems, want missing system")
+	}
+	want := ListError{File: "artificial.yaml", Line: 1, Repo: "name-only", Message: "missing property 'system'"}
+	if diff := cmp.Diff(want, problems[0]); diff != "" {
+		t.Errorf("ValidateLists() first problem mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = s.GetLists()
+	if !IsInvalidListError(err) {
+		t.Errorf("GetLists() error = %v, want invalidListError", err)
+	}
+}
+
+func TestListError(t *testing.T) {
+	got := ListError{File: "team.yaml", Line: 3, Repo: "app-operator", Message: "oops"}.Error()
+	if want := `team.yaml:3: repository "app-operator": oops`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
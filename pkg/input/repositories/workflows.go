@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	// Directory holding GitHub Actions workflows, relative to the default branch.
+	githubWorkflowsPath = ".github/workflows"
+
+	// Repository in our organization providing reusable workflows.
+	reusableWorkflowsRepository = "github-workflows"
+)
+
+// isWorkflowFile returns true if the file name in the workflows directory
+// denotes a GitHub Actions workflow.
+func isWorkflowFile(name string) bool {
+	ext := path.Ext(name)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// reusableWorkflows returns the sorted, distinct file names of the reusable
+// workflows from the organization's github-workflows repository that are
+// called by jobs of the given workflow definitions.
+func reusableWorkflows(organization string, workflows []string) []string {
+	prefix := strings.ToLower(organization + "/" + reusableWorkflowsRepository + "/" + githubWorkflowsPath + "/")
+
+	var result []string
+	for _, workflowYAML := range workflows {
+		var workflow struct {
+			Jobs map[string]struct {
+				Uses string `yaml:"uses"`
+			} `yaml:"jobs"`
+		}
+		if err := yaml.Unmarshal([]byte(workflowYAML), &workflow); err != nil {
+			continue
+		}
+
+		for _, job := range workflow.Jobs {
+			uses, _, _ := strings.Cut(job.Uses, "@")
+			if !strings.HasPrefix(strings.ToLower(uses), prefix) {
+				continue
+			}
+			name := uses[len(prefix):]
+			if !slices.Contains(result, name) {
+				result = append(result, name)
+			}
+		}
+	}
+
+	slices.Sort(result)
+
+	return result
+}
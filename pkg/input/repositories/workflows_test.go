@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReusableWorkflows(t *testing.T) {
+	workflows := []string{
+		`name: Create Release
+on:
+  push:
+    branches: [main]
+jobs:
+  gather_facts:
+    uses: giantswarm/github-workflows/.github/workflows/gather-facts.yaml@main
+  create_release:
+    uses: GiantSwarm/github-workflows/.github/workflows/create-release.yaml@v2
+    needs: gather_facts
+  local:
+    uses: ./.github/workflows/local.yaml
+  other_org:
+    uses: other/github-workflows/.github/workflows/other.yaml@v1
+  steps_only:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: giantswarm/github-workflows/.github/actions/some-action@v1
+`,
+		`jobs:
+  gather_facts:
+    uses: giantswarm/github-workflows/.github/workflows/gather-facts.yaml@v1
+`,
+		`invalid: [`,
+	}
+
+	want := []string{"create-release.yaml", "gather-facts.yaml"}
+	if diff := cmp.Diff(want, reusableWorkflows("giantswarm", workflows)); diff != "" {
+		t.Errorf("reusableWorkflows() mismatch (-want +got):\n%s", diff)
+	}
+}
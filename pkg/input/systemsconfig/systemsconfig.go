@@ -0,0 +1,167 @@
+// Package systemsconfig provides functionality to parse the configuration file
+// defining Backstage domains and the assignment of systems to domains.
+package systemsconfig
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/giantswarm/microerror"
+	"go.yaml.in/yaml/v3"
+)
+
+// Domain represents a domain configuration entry.
+type Domain struct {
+	// Name is the domain name (required).
+	Name string `yaml:"name"`
+
+	// Owner is the Backstage owner reference (required).
+	Owner string `yaml:"owner"`
+
+	// Title is the optional display title.
+	Title string `yaml:"title"`
+
+	// Description is the optional description.
+	Description string `yaml:"description"`
+
+	// SubdomainOf is the optional name of a parent domain.
+	SubdomainOf string `yaml:"subdomainOf"`
+}
+
+// System represents a system configuration entry. Systems not listed in
+// the configuration are exported anyway, with an inferred owner.
+type System struct {
+	// Name is the system name (required).
+	Name string `yaml:"name"`
+
+	// Domain is the optional name of the domain the system belongs to.
+	// Must be one of the configured domains.
+	Domain string `yaml:"domain"`
+
+	// Owner is the optional Backstage owner reference. If empty, the owner
+	// is inferred from the teams owning repositories in the system.
+	Owner string `yaml:"owner"`
+
+	// Title is the optional display title.
+	Title string `yaml:"title"`
+
+	// Description is the optional description.
+	Description string `yaml:"description"`
+}
+
+// File is the content of a systems configuration file.
+type File struct {
+	Domains []Domain `yaml:"domains"`
+	Systems []System `yaml:"systems"`
+}
+
+// Config holds the service configuration.
+type Config struct {
+	// Reader is the source to read configuration from.
+	// If nil, FilePath must be set.
+	Reader io.Reader
+
+	// FilePath is the path to the configuration file.
+	// Used if Reader is nil.
+	FilePath string
+}
+
+// Service provides systems configuration parsing functionality.
+type Service struct {
+	config Config
+}
+
+// New creates a new systems configuration service.
+func New(c Config) (*Service, error) {
+	if c.Reader == nil && c.FilePath == "" {
+		return nil, microerror.Maskf(invalidConfigError, "either Reader or FilePath must be provided")
+	}
+
+	return &Service{
+		config: c,
+	}, nil
+}
+
+// Load reads, parses and validates the systems configuration.
+func (s *Service) Load() (*File, error) {
+	var reader io.Reader
+
+	if s.config.Reader != nil {
+		reader = s.config.Reader
+	} else {
+		file, err := os.Open(s.config.FilePath)
+		if err != nil {
+			return nil, microerror.Maskf(fileNotFoundError, "failed to open config file: %v", err)
+		}
+		defer func() { _ = file.Close() }()
+		reader = file
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, microerror.Maskf(readError, "failed to read config: %v", err)
+	}
+
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, microerror.Maskf(parseError, "failed to parse YAML: %v", err)
+	}
+
+	if err := validate(&f); err != nil {
+		return nil, microerror.Maskf(validationError, "%v", err)
+	}
+
+	return &f, nil
+}
+
+// SystemByName returns the configuration entry for the given system,
+// or nil if the system is not configured.
+func (f *File) SystemByName(name string) *System {
+	for i := range f.Systems {
+		if f.Systems[i].Name == name {
+			return &f.Systems[i]
+		}
+	}
+	return nil
+}
+
+// validate checks required fields and references between entries.
+func validate(f *File) error {
+	domains := make(map[string]bool, len(f.Domains))
+	for i, d := range f.Domains {
+		if d.Name == "" {
+			return fmt.Errorf("domain %d: name is required", i+1)
+		}
+		if d.Owner == "" {
+			return fmt.Errorf("domain %q: owner is required", d.Name)
+		}
+		if domains[d.Name] {
+			return fmt.Errorf("domain %q: defined more than once", d.Name)
+		}
+		domains[d.Name] = true
+	}
+
+	for _, d := range f.Domains {
+		if d.SubdomainOf != "" && !domains[d.SubdomainOf] {
+			return fmt.Errorf("domain %q: unknown parent domain %q", d.Name, d.SubdomainOf)
+		}
+	}
+
+	systems := make(map[string]bool, len(f.Systems))
+	for i, sys := range f.Systems {
+		if sys.Name == "" {
+			return fmt.Errorf("system %d: name is required", i+1)
+		}
+		if systems[sys.Name] {
+			return fmt.Errorf("system %q: defined more than once", sys.Name)
+		}
+		systems[sys.Name] = true
+
+		if sys.Domain != "" && !domains[sys.Domain] {
+			return fmt.Errorf("system %q: unknown domain %q", sys.Name, sys.Domain)
+		}
+	}
+
+	return nil
+}
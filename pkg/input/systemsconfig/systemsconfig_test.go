@@ -0,0 +1,111 @@
+package systemsconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/microerror"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestService_Load(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        *File
+		wantErrType error
+	}{
+		{
+			name: "DomainsAndSystems",
+			input: `domains:
+- name: platform
+  owner: group:team-honeybadger
+  title: Platform
+- name: app-platform-domain
+  owner: group:team-honeybadger
+  subdomainOf: platform
+systems:
+- name: app-platform
+  domain: app-platform-domain
+  description: Deploying apps
+- name: observability
+  owner: group:team-atlas
+`,
+			want: &File{
+				Domains: []Domain{
+					{Name: "platform", Owner: "group:team-honeybadger", Title: "Platform"},
+					{Name: "app-platform-domain", Owner: "group:team-honeybadger", SubdomainOf: "platform"},
+				},
+				Systems: []System{
+					{Name: "app-platform", Domain: "app-platform-domain", Description: "Deploying apps"},
+					{Name: "observability", Owner: "group:team-atlas"},
+				},
+			},
+		},
+		{
+			name:  "Empty",
+			input: ``,
+			want:  &File{},
+		},
+		{
+			name:        "InvalidYAML",
+			input:       `domains: [`,
+			wantErrType: parseError,
+		},
+		{
+			name: "DomainWithoutOwner",
+			input: `domains:
+- name: platform
+`,
+			wantErrType: validationError,
+		},
+		{
+			name: "UnknownDomain",
+			input: `systems:
+- name: app-platform
+  domain: nope
+`,
+			wantErrType: validationError,
+		},
+		{
+			name: "UnknownParentDomain",
+			input: `domains:
+- name: platform
+  owner: group:team-honeybadger
+  subdomainOf: nope
+`,
+			wantErrType: validationError,
+		},
+		{
+			name: "DuplicateSystem",
+			input: `systems:
+- name: app-platform
+- name: app-platform
+`,
+			wantErrType: validationError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(Config{Reader: strings.NewReader(tt.input)})
+			if err != nil {
+				t.Fatalf("New() unexpected error: %v", err)
+			}
+
+			got, err := s.Load()
+			if tt.wantErrType != nil {
+				if microerror.Cause(err) != tt.wantErrType {
+					t.Errorf("Load() error = %v, want %v", err, tt.wantErrType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
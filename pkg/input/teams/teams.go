@@ -7,6 +7,7 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/google/go-github/v90/github"
 
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/httpclient"
 )
 
@@ -16,6 +17,13 @@ type Config struct {
 
 	// Github personal access token (PTA) to use for client authentication.
 	GithubAuthToken string
+
+	// GitHub client to use, e.g. one shared with other services. If nil,
+	// a new client is created using GithubAuthToken.
+	GithubClient *github.Client
+
+	// GitHub instance to use. Defaults to github.com.
+	GithubHost githubhost.Host
 }
 
 type Service struct {
@@ -29,14 +37,18 @@ func New(c Config) (*Service, error) {
 	if c.GithubOrganization == "" {
 		return nil, microerror.Maskf(invalidConfigError, "no Github organization configured")
 	}
-	if c.GithubAuthToken == "" {
+	if c.GithubAuthToken == "" && c.GithubClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "no Github token given")
 	}
 
 	ctx := context.Background()
-	client, err := httpclient.NewGitHubClient(c.GithubAuthToken)
-	if err != nil {
-		return nil, microerror.Mask(err)
+	client := c.GithubClient
+	if client == nil {
+		var err error
+		client, err = httpclient.NewGitHubClient(c.GithubAuthToken, httpclient.WithHost(c.GithubHost))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
 	}
 
 	s := &Service{
@@ -0,0 +1,12 @@
+package v1alpha1
+
+// DomainSpec contains the spec fields for a Domain entity in Backstage.
+//
+// See: https://backstage.io/docs/features/software-catalog/descriptor-format#kind-domain
+type DomainSpec struct {
+	// An entity reference to the owner of the domain. This field is required.
+	Owner string `yaml:"owner"`
+
+	// An entity reference to another domain of which the domain is a part.
+	SubdomainOf string `yaml:"subdomainOf,omitempty"`
+}
@@ -0,0 +1,12 @@
+package v1alpha1
+
+// SystemSpec contains the spec fields for a System entity in Backstage.
+//
+// See: https://backstage.io/docs/features/software-catalog/descriptor-format#kind-system
+type SystemSpec struct {
+	// An entity reference to the owner of the system. This field is required.
+	Owner string `yaml:"owner"`
+
+	// An entity reference to the domain that the system belongs to.
+	Domain string `yaml:"domain,omitempty"`
+}
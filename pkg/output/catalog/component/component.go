@@ -5,6 +5,7 @@ package component
 import (
 	"fmt"
 
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/input/helmchart"
 	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
 )
@@ -40,6 +41,9 @@ type Component struct {
 	// "<organization>/<repository>"
 	GithubProjectSlug string
 
+	// GitHub instance hosting the repository. Defaults to github.com.
+	GithubHost githubhost.Host
+
 	// Name of the GitHub team owning the component
 	// TODO: Specify whether organization name must be prefixed
 	GithubTeamSlug string
@@ -50,6 +54,13 @@ type Component struct {
 	// If the project has a CircleCI configuration, name of the project
 	CircleCiSlug string
 
+	// File names of the GitHub Actions workflows of the component repository.
+	GithubWorkflows []string
+
+	// File names of the reusable workflows from giantswarm/github-workflows
+	// used by the component repository.
+	ReusableWorkflows []string
+
 	// If the component type is "service", the 'backstage.io/kubernetes-id' annotation
 	// will be set to this value. If empty, the component name will be used.
 	KubernetesID string
@@ -69,9 +80,19 @@ type Component struct {
 	// Component lifecycle styge. Defaults to "production".
 	Lifecycle string
 
-	// Names of components that this component depends on.
+	// Names of components that this component depends on. Entries may also
+	// be entity references of other kinds, e.g. "resource:some-database".
 	DependsOn []string
 
+	// Entity references of the APIs provided by the component.
+	ProvidesAPIs []string
+
+	// Entity references of the APIs consumed by the component.
+	ConsumesAPIs []string
+
+	// Name of the component this component is a part of.
+	SubcomponentOf string
+
 	// Programming language of the component (optional, used for labels/tags).
 	Language string
 
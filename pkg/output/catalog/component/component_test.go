@@ -178,6 +178,37 @@ func TestComponent_ToEntity(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:          "WithGithubWorkflows",
+			componentName: "actions-component",
+			options: []Option{
+				WithGithubProjectSlug("giantswarm/actions-component"),
+				WithGithubWorkflows("zz_generated.create_release.yaml", "ci.yaml"),
+				WithReusableWorkflows("create-release.yaml"),
+			},
+			want: &bscatalog.Entity{
+				APIVersion: bscatalog.APIVersion,
+				Kind:       bscatalog.EntityKindComponent,
+				Metadata: bscatalog.EntityMetadata{
+					Name:   "actions-component",
+					Labels: map[string]string{},
+					Annotations: map[string]string{
+						"github.com/project-slug":                 "giantswarm/actions-component",
+						"backstage.io/source-location":            "url:https://github.com/giantswarm/actions-component",
+						"giantswarm.io/github-workflows":          "zz_generated.create_release.yaml,ci.yaml",
+						"giantswarm.io/github-reusable-workflows": "create-release.yaml",
+					},
+					Links: []bscatalog.EntityLink{},
+					Tags:  []string{"ci:github-actions"},
+				},
+				Spec: bscatalog.ComponentSpec{
+					Type:      "unspecified",
+					Lifecycle: "production",
+					Owner:     "unspecified",
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name:          "WithHelmChartAudienceAll",
 			componentName: "chart-audience-component",
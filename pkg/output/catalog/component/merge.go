@@ -0,0 +1,109 @@
+package component
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+)
+
+// Merge merges the metadata and spec of a hand-maintained component
+// descriptor, e.g. from a catalog-info.yaml file in the component's
+// repository, into the Component. Generated values take precedence:
+//
+//   - Single values (title, description, type, lifecycle, owner, system,
+//     subcomponentOf) are taken from the descriptor only if the Component
+//     has no value, or the default value, for them.
+//   - Annotations and labels are added, unless the generated entity already
+//     has the key.
+//   - Tags, links (compared by URL), provided and consumed APIs, and
+//     dependencies are added to the generated ones.
+//
+// Merge returns a description of every value of the descriptor that was
+// ignored because it conflicts with the generated one.
+func (c *Component) Merge(metadata bscatalog.EntityMetadata, spec bscatalog.ComponentSpec) []string {
+	generated := c.ToEntity()
+
+	var conflicts []string
+	mergeValue := func(field string, current *string, defaultValue, value string) {
+		if value == "" || value == *current {
+			return
+		}
+		if *current == "" || *current == defaultValue {
+			*current = value
+			return
+		}
+		conflicts = append(conflicts, fmt.Sprintf("%s %q conflicts with generated value %q", field, value, *current))
+	}
+
+	if metadata.Namespace != "" && metadata.Namespace != c.Namespace {
+		conflicts = append(conflicts, fmt.Sprintf("metadata.namespace %q conflicts with generated value %q", metadata.Namespace, c.Namespace))
+	}
+	mergeValue("metadata.title", &c.Title, "", metadata.Title)
+	mergeValue("metadata.description", &c.Description, "", metadata.Description)
+	mergeValue("spec.type", &c.Type, defaultType, spec.Type)
+	mergeValue("spec.lifecycle", &c.Lifecycle, defaultLifecycle, spec.Lifecycle)
+	mergeValue("spec.owner", &c.Owner, defaultOwner, strings.TrimPrefix(spec.Owner, "group:"))
+	mergeValue("spec.system", &c.System, "", strings.TrimPrefix(spec.System, "system:"))
+	mergeValue("spec.subcomponentOf", &c.SubcomponentOf, "", strings.TrimPrefix(spec.SubcomponentOf, "component:"))
+
+	for _, key := range sortedKeys(metadata.Annotations) {
+		value := metadata.Annotations[key]
+		if generatedValue, exists := generated.Metadata.Annotations[key]; exists {
+			if value != generatedValue {
+				conflicts = append(conflicts, fmt.Sprintf("annotation %s %q conflicts with generated value %q", key, value, generatedValue))
+			}
+			continue
+		}
+		c.SetAnnotation(key, value)
+	}
+
+	for _, key := range sortedKeys(metadata.Labels) {
+		value := metadata.Labels[key]
+		if generatedValue, exists := generated.Metadata.Labels[key]; exists {
+			if value != generatedValue {
+				conflicts = append(conflicts, fmt.Sprintf("label %s %q conflicts with generated value %q", key, value, generatedValue))
+			}
+			continue
+		}
+		if c.Labels == nil {
+			c.Labels = make(map[string]string)
+		}
+		c.Labels[key] = value
+	}
+
+	for _, tag := range metadata.Tags {
+		if !slices.Contains(generated.Metadata.Tags, tag) && !slices.Contains(c.Tags, tag) {
+			c.AddTag(tag)
+		}
+	}
+
+	for _, link := range metadata.Links {
+		if !slices.ContainsFunc(c.Links, func(l bscatalog.EntityLink) bool { return l.URL == link.URL }) {
+			c.AddLink(link)
+		}
+	}
+
+	c.ProvidesAPIs = appendMissing(c.ProvidesAPIs, spec.ProvidesAPIs...)
+	c.ConsumesAPIs = appendMissing(c.ConsumesAPIs, spec.ConsumesAPIs...)
+	for _, d := range spec.DependsOn {
+		c.DependsOn = appendMissing(c.DependsOn, strings.TrimPrefix(d, "component:"))
+	}
+
+	return conflicts
+}
+
+func appendMissing(list []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func sortedKeys(m map[string]string) []string {
+	return slices.Sorted(maps.Keys(m))
+}
@@ -0,0 +1,94 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+)
+
+func TestMerge(t *testing.T) {
+	c, err := New("my-service",
+		WithOwner("team-a"),
+		WithType("service"),
+		WithDescription("Generated description"),
+		WithGithubProjectSlug("giantswarm/my-service"),
+		WithDependsOn("some-library"),
+		WithTags("existing"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	c.AddLink(bscatalog.EntityLink{URL: "https://example.com/dashboard", Title: "Dashboard"})
+
+	conflicts := c.Merge(
+		bscatalog.EntityMetadata{
+			Name:        "my-service",
+			Title:       "My Service",
+			Description: "Hand-written description",
+			Annotations: map[string]string{
+				"github.com/project-slug":  "giantswarm/other",
+				"pagerduty.com/service-id": "ABC123",
+			},
+			Labels: map[string]string{"tier": "1"},
+			Tags:   []string{"existing", "hand-maintained"},
+			Links: []bscatalog.EntityLink{
+				{URL: "https://example.com/dashboard", Title: "Other title"},
+				{URL: "https://example.com/runbook", Title: "Runbook"},
+			},
+		},
+		bscatalog.ComponentSpec{
+			Type:         "service",
+			Lifecycle:    "experimental",
+			Owner:        "group:team-b",
+			System:       "system:my-system",
+			ProvidesAPIs: []string{"my-api"},
+			DependsOn:    []string{"component:some-library", "resource:some-database"},
+		},
+	)
+
+	wantConflicts := []string{
+		`metadata.description "Hand-written description" conflicts with generated value "Generated description"`,
+		`spec.owner "team-b" conflicts with generated value "team-a"`,
+		`annotation github.com/project-slug "giantswarm/other" conflicts with generated value "giantswarm/my-service"`,
+	}
+	if diff := cmp.Diff(wantConflicts, conflicts); diff != "" {
+		t.Errorf("Merge() conflicts mismatch (-want +got):\n%s", diff)
+	}
+
+	e := c.ToEntity()
+
+	wantMetadata := bscatalog.EntityMetadata{
+		Name:        "my-service",
+		Title:       "My Service",
+		Description: "Generated description",
+		Annotations: map[string]string{
+			"backstage.io/kubernetes-id":   "my-service",
+			"backstage.io/source-location": "url:https://github.com/giantswarm/my-service",
+			"github.com/project-slug":      "giantswarm/my-service",
+			"pagerduty.com/service-id":     "ABC123",
+		},
+		Labels: map[string]string{"tier": "1"},
+		Tags:   []string{"existing", "hand-maintained"},
+		Links: []bscatalog.EntityLink{
+			{URL: "https://example.com/dashboard", Title: "Dashboard"},
+			{URL: "https://example.com/runbook", Title: "Runbook"},
+		},
+	}
+	if diff := cmp.Diff(wantMetadata, e.Metadata); diff != "" {
+		t.Errorf("Merge() metadata mismatch (-want +got):\n%s", diff)
+	}
+
+	wantSpec := bscatalog.ComponentSpec{
+		Type:         "service",
+		Lifecycle:    "experimental",
+		Owner:        "team-a",
+		System:       "my-system",
+		ProvidesAPIs: []string{"my-api"},
+		DependsOn:    []string{"component:some-library", "resource:some-database"},
+	}
+	if diff := cmp.Diff(wantSpec, e.Spec); diff != "" {
+		t.Errorf("Merge() spec mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -1,6 +1,7 @@
 package component
 
 import (
+	"github.com/giantswarm/backstage-catalog-importer/pkg/githubhost"
 	"github.com/giantswarm/backstage-catalog-importer/pkg/input/helmchart"
 )
 
@@ -43,6 +44,18 @@ func WithCircleCiSlug(slug string) Option {
 	}
 }
 
+func WithGithubWorkflows(names ...string) Option {
+	return func(c *Component) {
+		c.GithubWorkflows = names
+	}
+}
+
+func WithReusableWorkflows(names ...string) Option {
+	return func(c *Component) {
+		c.ReusableWorkflows = names
+	}
+}
+
 func WithHasReadme(hasReadme bool) Option {
 	return func(c *Component) {
 		c.HasReadme = hasReadme
@@ -61,6 +74,12 @@ func WithGithubProjectSlug(slug string) Option {
 	}
 }
 
+func WithGithubHost(host githubhost.Host) Option {
+	return func(c *Component) {
+		c.GithubHost = host
+	}
+}
+
 func WithType(t string) Option {
 	return func(c *Component) {
 		c.Type = t
@@ -81,6 +100,24 @@ func WithDependsOn(dependsOn ...string) Option {
 	}
 }
 
+func WithProvidesAPIs(apis ...string) Option {
+	return func(c *Component) {
+		c.ProvidesAPIs = apis
+	}
+}
+
+func WithConsumesAPIs(apis ...string) Option {
+	return func(c *Component) {
+		c.ConsumesAPIs = apis
+	}
+}
+
+func WithSubcomponentOf(name string) Option {
+	return func(c *Component) {
+		c.SubcomponentOf = name
+	}
+}
+
 func WithLanguage(language string) Option {
 	return func(c *Component) {
 		c.Language = language
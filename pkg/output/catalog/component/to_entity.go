@@ -2,6 +2,7 @@ package component
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
@@ -24,6 +25,9 @@ func (c *Component) ToEntity() *bscatalog.Entity {
 	if len(c.HelmCharts) > 0 {
 		tags = append(tags, "helmchart")
 	}
+	if len(c.GithubWorkflows) > 0 {
+		tags = append(tags, "ci:github-actions")
+	}
 	sort.Strings(tags)
 
 	e := &bscatalog.Entity{
@@ -87,9 +91,9 @@ func (c *Component) ToEntity() *bscatalog.Entity {
 
 	if c.GithubProjectSlug != "" {
 		e.Metadata.Annotations["github.com/project-slug"] = c.GithubProjectSlug
-		e.Metadata.Annotations["backstage.io/source-location"] = fmt.Sprintf("url:https://github.com/%s", c.GithubProjectSlug)
+		e.Metadata.Annotations["backstage.io/source-location"] = "url:" + c.GithubHost.RepoURL(c.GithubProjectSlug)
 		if c.HasReadme && c.DefaultBranch != "" {
-			e.Metadata.Annotations["backstage.io/techdocs-ref"] = fmt.Sprintf("url:https://github.com/%s/tree/%s", c.GithubProjectSlug, c.DefaultBranch)
+			e.Metadata.Annotations["backstage.io/techdocs-ref"] = "url:" + c.GithubHost.TreeURL(c.GithubProjectSlug, c.DefaultBranch, "")
 		}
 	}
 	if c.GithubTeamSlug != "" {
@@ -98,6 +102,12 @@ func (c *Component) ToEntity() *bscatalog.Entity {
 	if c.CircleCiSlug != "" {
 		e.Metadata.Annotations["circleci.com/project-slug"] = c.CircleCiSlug
 	}
+	if len(c.GithubWorkflows) > 0 {
+		e.Metadata.Annotations["giantswarm.io/github-workflows"] = strings.Join(c.GithubWorkflows, ",")
+	}
+	if len(c.ReusableWorkflows) > 0 {
+		e.Metadata.Annotations["giantswarm.io/github-reusable-workflows"] = strings.Join(c.ReusableWorkflows, ",")
+	}
 	if c.Type == typeService {
 		e.Metadata.Annotations["backstage.io/kubernetes-id"] = c.Name
 		if c.KubernetesID != "" {
@@ -129,12 +139,25 @@ func (c *Component) ToEntity() *bscatalog.Entity {
 	if c.System != "" {
 		spec.System = c.System
 	}
+	if c.SubcomponentOf != "" {
+		spec.SubcomponentOf = c.SubcomponentOf
+	}
+	if len(c.ProvidesAPIs) > 0 {
+		spec.ProvidesAPIs = slices.Sorted(slices.Values(c.ProvidesAPIs))
+	}
+	if len(c.ConsumesAPIs) > 0 {
+		spec.ConsumesAPIs = slices.Sorted(slices.Values(c.ConsumesAPIs))
+	}
 	if len(c.DependsOn) > 0 {
-		sort.Strings(c.DependsOn)
+		spec.DependsOn = make([]string, len(c.DependsOn))
 		for i, d := range c.DependsOn {
-			c.DependsOn[i] = "component:" + d
+			// Plain names refer to components.
+			if !strings.Contains(d, ":") {
+				d = "component:" + d
+			}
+			spec.DependsOn[i] = d
 		}
-		spec.DependsOn = c.DependsOn
+		sort.Strings(spec.DependsOn)
 	}
 
 	e.Metadata.NormalizeTags()
@@ -0,0 +1,57 @@
+// Package domain provides high level structs and functions to create catalog entities
+// of kind "Domain" in an opinionated way.
+package domain
+
+import "fmt"
+
+const (
+	defaultNamespace = "default"
+	defaultOwner     = "unspecified"
+)
+
+// Domain holds our internal representation of something that we want
+// to export as a Backstage entity of kind "Domain".
+type Domain struct {
+	// Name is the domain name (required).
+	Name string
+
+	// Namespace defaults to "default".
+	Namespace string
+
+	// Title is the display title of the domain.
+	Title string
+
+	// Description of the domain.
+	Description string
+
+	// Owner is the entity reference to the owner. Defaults to "unspecified".
+	Owner string
+
+	// SubdomainOf is an optional reference to a parent domain.
+	SubdomainOf string
+
+	// Tags for categorization.
+	Tags []string
+
+	// Annotations for non-identifying metadata.
+	Annotations map[string]string
+}
+
+// New creates a new Domain with the given name and options.
+func New(name string, options ...Option) (*Domain, error) {
+	if name == "" {
+		return nil, fmt.Errorf("name must not be empty")
+	}
+
+	d := &Domain{
+		Name:      name,
+		Namespace: defaultNamespace,
+		Owner:     defaultOwner,
+	}
+
+	for _, option := range options {
+		option(d)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+)
+
+func TestDomain_ToEntity(t *testing.T) {
+	d, err := New("app-platform-domain",
+		WithTitle("App Platform"),
+		WithDescription("Everything about apps"),
+		WithOwner("group:team-honeybadger"),
+		WithSubdomainOf("platform"),
+	)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+
+	want := &bscatalog.Entity{
+		APIVersion: bscatalog.APIVersion,
+		Kind:       bscatalog.EntityKindDomain,
+		Metadata: bscatalog.EntityMetadata{
+			Name:        "app-platform-domain",
+			Title:       "App Platform",
+			Description: "Everything about apps",
+		},
+		Spec: bscatalog.DomainSpec{
+			Owner:       "group:team-honeybadger",
+			SubdomainOf: "platform",
+		},
+	}
+	if diff := cmp.Diff(want, d.ToEntity()); diff != "" {
+		t.Errorf("Domain.ToEntity() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,43 @@
+package domain
+
+// Option is a functional option for configuring a Domain.
+type Option func(*Domain)
+
+// WithNamespace sets the Backstage namespace.
+func WithNamespace(namespace string) Option {
+	return func(d *Domain) {
+		if namespace != "" {
+			d.Namespace = namespace
+		}
+	}
+}
+
+// WithTitle sets the display title.
+func WithTitle(title string) Option {
+	return func(d *Domain) {
+		d.Title = title
+	}
+}
+
+// WithDescription sets the description.
+func WithDescription(description string) Option {
+	return func(d *Domain) {
+		d.Description = description
+	}
+}
+
+// WithOwner sets the owner reference.
+func WithOwner(owner string) Option {
+	return func(d *Domain) {
+		if owner != "" {
+			d.Owner = owner
+		}
+	}
+}
+
+// WithSubdomainOf sets the parent domain reference.
+func WithSubdomainOf(parent string) Option {
+	return func(d *Domain) {
+		d.SubdomainOf = parent
+	}
+}
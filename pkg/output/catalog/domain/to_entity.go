@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"maps"
+
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+)
+
+// ToEntity converts the Domain to a Backstage entity.
+func (d *Domain) ToEntity() *bscatalog.Entity {
+	e := &bscatalog.Entity{
+		APIVersion: bscatalog.APIVersion,
+		Kind:       bscatalog.EntityKindDomain,
+		Metadata: bscatalog.EntityMetadata{
+			Name:        d.Name,
+			Title:       d.Title,
+			Description: d.Description,
+			Tags:        d.Tags,
+		},
+	}
+
+	// Only set namespace if not "default"
+	if d.Namespace != defaultNamespace {
+		e.Metadata.Namespace = d.Namespace
+	}
+
+	// Copy annotations
+	if len(d.Annotations) > 0 {
+		e.Metadata.Annotations = make(map[string]string)
+		maps.Copy(e.Metadata.Annotations, d.Annotations)
+	}
+
+	e.Spec = bscatalog.DomainSpec{
+		Owner:       d.Owner,
+		SubdomainOf: d.SubdomainOf,
+	}
+
+	e.Metadata.NormalizeTags()
+
+	return e
+}
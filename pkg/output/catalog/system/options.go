@@ -0,0 +1,50 @@
+package system
+
+// Option is a functional option for configuring a System.
+type Option func(*System)
+
+// WithNamespace sets the Backstage namespace.
+func WithNamespace(namespace string) Option {
+	return func(s *System) {
+		if namespace != "" {
+			s.Namespace = namespace
+		}
+	}
+}
+
+// WithTitle sets the display title.
+func WithTitle(title string) Option {
+	return func(s *System) {
+		s.Title = title
+	}
+}
+
+// WithDescription sets the description.
+func WithDescription(description string) Option {
+	return func(s *System) {
+		s.Description = description
+	}
+}
+
+// WithOwner sets the owner reference.
+func WithOwner(owner string) Option {
+	return func(s *System) {
+		if owner != "" {
+			s.Owner = owner
+		}
+	}
+}
+
+// WithDomain sets the domain reference.
+func WithDomain(domain string) Option {
+	return func(s *System) {
+		s.Domain = domain
+	}
+}
+
+// WithTags adds tags to the System.
+func WithTags(tags ...string) Option {
+	return func(s *System) {
+		s.Tags = append(s.Tags, tags...)
+	}
+}
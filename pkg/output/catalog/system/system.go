@@ -0,0 +1,86 @@
+// Package system provides high level structs and functions to create catalog entities
+// of kind "System" in an opinionated way.
+package system
+
+import (
+	"fmt"
+
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+)
+
+const (
+	defaultNamespace = "default"
+	defaultOwner     = "unspecified"
+)
+
+// System holds our internal representation of something that we want
+// to export as a Backstage entity of kind "System".
+type System struct {
+	// Name is the system name (required).
+	Name string
+
+	// Namespace defaults to "default".
+	Namespace string
+
+	// Title is the display title of the system.
+	Title string
+
+	// Description of the system.
+	Description string
+
+	// Owner is the entity reference to the owner. Defaults to "unspecified".
+	Owner string
+
+	// Domain is an optional reference to the domain the system belongs to.
+	Domain string
+
+	// Tags for categorization.
+	Tags []string
+
+	// Labels for key/value metadata.
+	Labels map[string]string
+
+	// Annotations for non-identifying metadata.
+	Annotations map[string]string
+
+	// Links to external resources.
+	Links []bscatalog.EntityLink
+}
+
+// New creates a new System with the given name and options.
+func New(name string, options ...Option) (*System, error) {
+	if name == "" {
+		return nil, fmt.Errorf("name must not be empty")
+	}
+
+	s := &System{
+		Name:      name,
+		Namespace: defaultNamespace,
+		Owner:     defaultOwner,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s, nil
+}
+
+// AddTag adds a tag to the System.
+func (s *System) AddTag(tag string) {
+	s.Tags = append(s.Tags, tag)
+}
+
+// AddLink adds an entity link to the System.
+func (s *System) AddLink(link bscatalog.EntityLink) {
+	s.Links = append(s.Links, link)
+}
+
+// SetAnnotation sets an annotation on the System.
+// This overwrites the value if the key already exists.
+func (s *System) SetAnnotation(key, value string) {
+	if s.Annotations == nil {
+		s.Annotations = make(map[string]string)
+	}
+	s.Annotations[key] = value
+}
@@ -0,0 +1,77 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+)
+
+func TestSystem_ToEntity(t *testing.T) {
+	tests := []struct {
+		name       string
+		systemName string
+		options    []Option
+		want       *bscatalog.Entity
+	}{
+		{
+			name:       "Minimal",
+			systemName: "minimal",
+			want: &bscatalog.Entity{
+				APIVersion: bscatalog.APIVersion,
+				Kind:       bscatalog.EntityKindSystem,
+				Metadata: bscatalog.EntityMetadata{
+					Name: "minimal",
+				},
+				Spec: bscatalog.SystemSpec{
+					Owner: "unspecified",
+				},
+			},
+		},
+		{
+			name:       "Full",
+			systemName: "app-platform",
+			options: []Option{
+				WithNamespace("giantswarm"),
+				WithTitle("App Platform"),
+				WithDescription("Deploying apps"),
+				WithOwner("team-honeybadger"),
+				WithDomain("platform"),
+				WithTags("Some Tag"),
+			},
+			want: &bscatalog.Entity{
+				APIVersion: bscatalog.APIVersion,
+				Kind:       bscatalog.EntityKindSystem,
+				Metadata: bscatalog.EntityMetadata{
+					Name:        "app-platform",
+					Namespace:   "giantswarm",
+					Title:       "App Platform",
+					Description: "Deploying apps",
+					Tags:        []string{"some-tag"},
+				},
+				Spec: bscatalog.SystemSpec{
+					Owner:  "team-honeybadger",
+					Domain: "platform",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.systemName, tt.options...)
+			if err != nil {
+				t.Fatalf("New() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, s.ToEntity()); diff != "" {
+				t.Errorf("System.ToEntity() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNew_EmptyName(t *testing.T) {
+	if _, err := New(""); err == nil {
+		t.Error("New() expected error for empty name")
+	}
+}
@@ -0,0 +1,49 @@
+package system
+
+import (
+	"maps"
+
+	bscatalog "github.com/giantswarm/backstage-catalog-importer/pkg/output/bscatalog/v1alpha1"
+)
+
+// ToEntity converts the System to a Backstage entity.
+func (s *System) ToEntity() *bscatalog.Entity {
+	e := &bscatalog.Entity{
+		APIVersion: bscatalog.APIVersion,
+		Kind:       bscatalog.EntityKindSystem,
+		Metadata: bscatalog.EntityMetadata{
+			Name:        s.Name,
+			Title:       s.Title,
+			Description: s.Description,
+			Tags:        s.Tags,
+			Links:       s.Links,
+		},
+	}
+
+	// Only set namespace if not "default"
+	if s.Namespace != defaultNamespace {
+		e.Metadata.Namespace = s.Namespace
+	}
+
+	// Copy annotations
+	if len(s.Annotations) > 0 {
+		e.Metadata.Annotations = make(map[string]string)
+		maps.Copy(e.Metadata.Annotations, s.Annotations)
+	}
+
+	// Copy labels
+	if len(s.Labels) > 0 {
+		e.Metadata.Labels = make(map[string]string)
+		maps.Copy(e.Metadata.Labels, s.Labels)
+	}
+
+	e.Spec = bscatalog.SystemSpec{
+		Owner:  s.Owner,
+		Domain: s.Domain,
+	}
+
+	// Normalize tags (lowercase, replace special chars)
+	e.Metadata.NormalizeTags()
+
+	return e
+}
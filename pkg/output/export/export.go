@@ -4,6 +4,7 @@ package export
 import (
 	"bytes"
 	"cmp"
+	"fmt"
 	"os"
 	"slices"
 
@@ -21,6 +22,26 @@ type Service struct {
 	buffer     bytes.Buffer
 }
 
+// Summary describes the content of a written file.
+type Summary struct {
+	// Plural name of the exported entities, e.g. "components".
+	Name string
+
+	// Number of entities.
+	Count int
+
+	// Path of the file.
+	TargetPath string
+
+	// Size of the file in bytes.
+	Size int
+}
+
+// String returns the summary as a sentence.
+func (s Summary) String() string {
+	return fmt.Sprintf("%d %s written to file %s with size %d bytes", s.Count, s.Name, s.TargetPath, s.Size)
+}
+
 type Config struct {
 	// Path of the file that will be written.
 	TargetPath string
@@ -102,6 +123,17 @@ func (s *Service) WriteFile() error {
 	return nil
 }
 
+// Summary returns the summary of the collection, naming the entities as
+// given, e.g. "components".
+func (s *Service) Summary(name string) Summary {
+	return Summary{
+		Name:       name,
+		Count:      len(s.collection),
+		TargetPath: s.TargetPath,
+		Size:       s.Len(),
+	}
+}
+
 func (s *Service) String() string {
 	_ = s.updateBuffer()
 	return s.buffer.String()